@@ -0,0 +1,239 @@
+package talker
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// BackpressurePolicy decides what AsyncEvents does when its queue is full.
+type BackpressurePolicy int
+
+const (
+	BlockWhenFull      BackpressurePolicy = iota // BlockWhenFull makes the caller wait until there is room in the queue.
+	DropNewestWhenFull                           // DropNewestWhenFull drops the event that is being sent.
+	DropOldestWhenFull                           // DropOldestWhenFull drops the oldest event in the queue to make room.
+)
+
+// AsyncOptions is the configuration for the NewAsyncEvents function.
+type AsyncOptions struct {
+	QueueSize int                // QueueSize is the capacity of the queue, default is 1024.
+	Workers   int                // Workers is the number of goroutines calling the hook, default is 1.
+	Policy    BackpressurePolicy // Policy decides what happens when the queue is full, default is BlockWhenFull.
+}
+
+// AsyncEvents dispatches events to an EventHook on worker goroutines through a bounded queue,
+// so a slow hook does not stall the goroutine that sends the event.
+// This struct must be created with the NewAsyncEvents function.
+type AsyncEvents struct {
+	hook  EventHook
+	opts  AsyncOptions
+	queue chan asyncEvent
+
+	closeMu   sync.RWMutex
+	closed    bool
+	closing   chan struct{} // closing is closed when Close is called, it releases the blocked callers.
+	closeOnce sync.Once
+	workers   sync.WaitGroup
+
+	mu      sync.Mutex
+	pending int
+	idle    chan struct{}
+
+	dropped atomic.Uint64
+}
+
+type asyncEvent struct {
+	ctx   context.Context
+	name  string
+	attrs map[string]any
+}
+
+// NewAsyncEvents creates a new AsyncEvents that sends events to the given hook and starts its workers.
+// Example:
+//
+//	async := talker.NewAsyncEvents(slowHook, talker.AsyncOptions{QueueSize: 4096, Policy: talker.DropOldestWhenFull})
+//	pwr := talker.NewPower().WithEventHook(async.Hook())
+//
+//	proc := talker.Process{
+//		// ...
+//		Stop: async.Close, // Deliver the queued events before the process exits
+//	}
+func NewAsyncEvents(hook EventHook, opts AsyncOptions) *AsyncEvents {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 1024
+	}
+
+	if opts.Workers <= 0 {
+		opts.Workers = 1
+	}
+
+	idle := make(chan struct{})
+	close(idle)
+
+	a := &AsyncEvents{
+		hook:    hook,
+		opts:    opts,
+		queue:   make(chan asyncEvent, opts.QueueSize),
+		closing: make(chan struct{}),
+		idle:    idle,
+	}
+
+	for i := 0; i < opts.Workers; i++ {
+		a.workers.Add(1)
+
+		go a.work()
+	}
+
+	return a
+}
+
+// Hook returns an EventHook that queues the event instead of calling the wrapped hook directly.
+// Events sent after Close are dropped, and so are the events of callers blocked by BlockWhenFull when Close is called.
+func (a *AsyncEvents) Hook() EventHook {
+	return func(ctx context.Context, name string, attrs map[string]any) {
+		a.enqueue(asyncEvent{ctx: context.WithoutCancel(ctx), name: name, attrs: attrs})
+	}
+}
+
+// Dropped returns the number of events that have been dropped.
+func (a *AsyncEvents) Dropped() uint64 {
+	return a.dropped.Load()
+}
+
+// Flush waits until every queued event has been delivered or the context is done.
+func (a *AsyncEvents) Flush(ctx context.Context) error {
+	a.mu.Lock()
+	idle := a.idle
+	a.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting events, delivers the queued events and stops the workers.
+// It returns early with the context error when the context is done first.
+// The signature matches Callback, so it can be used as (part of) Process.Stop.
+func (a *AsyncEvents) Close(ctx context.Context) error {
+	a.closeOnce.Do(func() { close(a.closing) })
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// The blocked callers are released by closing, so the lock is not held for long.
+	a.closeMu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.closeMu.Unlock()
+
+	done := make(chan struct{})
+
+	go func() {
+		a.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (a *AsyncEvents) enqueue(e asyncEvent) {
+	select {
+	case <-a.closing:
+		a.dropped.Add(1)
+		return
+	default:
+	}
+
+	a.closeMu.RLock()
+	defer a.closeMu.RUnlock()
+
+	if a.closed {
+		a.dropped.Add(1)
+		return
+	}
+
+	// Count the event before it is queued, so Flush can not miss it.
+	a.mu.Lock()
+	a.begin()
+	a.mu.Unlock()
+
+	switch a.opts.Policy {
+	case DropNewestWhenFull:
+		select {
+		case a.queue <- e:
+		default:
+			a.drop()
+		}
+	case DropOldestWhenFull:
+		for {
+			select {
+			case a.queue <- e:
+				return
+			default:
+			}
+
+			select {
+			case <-a.queue:
+				a.drop()
+			default:
+			}
+		}
+	default:
+		select {
+		case a.queue <- e:
+		case <-a.closing:
+			a.drop()
+		}
+	}
+}
+
+func (a *AsyncEvents) drop() {
+	a.dropped.Add(1)
+
+	a.mu.Lock()
+	a.done()
+	a.mu.Unlock()
+}
+
+// begin and done must be called with the mutex held.
+func (a *AsyncEvents) begin() {
+	if a.pending == 0 {
+		a.idle = make(chan struct{})
+	}
+
+	a.pending++
+}
+
+func (a *AsyncEvents) done() {
+	a.pending--
+
+	if a.pending == 0 {
+		close(a.idle)
+	}
+}
+
+func (a *AsyncEvents) work() {
+	defer a.workers.Done()
+
+	for e := range a.queue {
+		// A panicking hook is handled like on the caller, check out Power.WithHookPanicHandler.
+		pwr, _ := PowerFromContext(e.ctx)
+		pwr.callEventHook(a.hook, e.ctx, e.name, e.attrs)
+
+		a.mu.Lock()
+		a.done()
+		a.mu.Unlock()
+	}
+}
@@ -0,0 +1,160 @@
+package talker_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Arsfiqball/csverse/talker"
+)
+
+func TestAsyncEvents(t *testing.T) {
+	t.Run("flush and close", func(t *testing.T) {
+		var count atomic.Int64
+
+		async := talker.NewAsyncEvents(func(ctx context.Context, name string, attrs map[string]any) {
+			time.Sleep(time.Millisecond)
+			count.Add(1)
+		}, talker.AsyncOptions{Workers: 2})
+
+		ctx := talker.NewPower().WithEventHook(async.Hook()).Context(context.Background(), "test")
+
+		for i := 0; i < 10; i++ {
+			talker.Event(ctx, "tick", nil)
+		}
+
+		if err := async.Flush(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		if count.Load() != 10 {
+			t.Fatalf("expected 10 delivered events, got %d", count.Load())
+		}
+
+		if err := async.Close(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		talker.Event(ctx, "late", nil)
+
+		if async.Dropped() != 1 {
+			t.Fatal("event after close is not dropped")
+		}
+	})
+
+	policy := func(t *testing.T, policy talker.BackpressurePolicy, expected string) {
+		release := make(chan struct{})
+		delivered := make(chan string, 10)
+
+		async := talker.NewAsyncEvents(func(ctx context.Context, name string, attrs map[string]any) {
+			<-release
+			delivered <- name
+		}, talker.AsyncOptions{QueueSize: 1, Policy: policy})
+
+		hook := async.Hook()
+		hook(context.Background(), "a", nil) // taken by the worker
+		time.Sleep(10 * time.Millisecond)
+		hook(context.Background(), "b", nil) // fills the queue
+		hook(context.Background(), "c", nil) // queue is full
+
+		close(release)
+
+		if err := async.Close(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		close(delivered)
+
+		got := ""
+		for name := range delivered {
+			got += name
+		}
+
+		if got != expected {
+			t.Fatalf("expected %q to be delivered, got %q", expected, got)
+		}
+
+		if async.Dropped() != 1 {
+			t.Fatalf("expected 1 dropped event, got %d", async.Dropped())
+		}
+	}
+
+	t.Run("drop newest", func(t *testing.T) { policy(t, talker.DropNewestWhenFull, "ab") })
+	t.Run("drop oldest", func(t *testing.T) { policy(t, talker.DropOldestWhenFull, "ac") })
+
+	t.Run("panicking hook", func(t *testing.T) {
+		var panics atomic.Int64
+
+		async := talker.NewAsyncEvents(func(ctx context.Context, name string, attrs map[string]any) {
+			panic("boom")
+		}, talker.AsyncOptions{})
+
+		ctx := talker.NewPower().
+			WithEventHook(async.Hook()).
+			WithHookPanicHandler(func(ctx context.Context, name string, recovered any) { panics.Add(1) }).
+			Context(context.Background(), "test")
+
+		talker.Event(ctx, "a", nil)
+		talker.Event(ctx, "b", nil)
+
+		if err := async.Flush(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := async.Close(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		if panics.Load() != 2 {
+			t.Fatalf("expected 2 handled panics, got %d", panics.Load())
+		}
+	})
+
+	t.Run("close while blocked", func(t *testing.T) {
+		release := make(chan struct{})
+
+		async := talker.NewAsyncEvents(func(ctx context.Context, name string, attrs map[string]any) {
+			<-release
+		}, talker.AsyncOptions{QueueSize: 1, Policy: talker.BlockWhenFull})
+
+		hook := async.Hook()
+		hook(context.Background(), "a", nil) // taken by the worker
+		time.Sleep(10 * time.Millisecond)
+		hook(context.Background(), "b", nil) // fills the queue
+
+		blocked := make(chan struct{})
+
+		go func() {
+			hook(context.Background(), "c", nil) // queue is full
+			close(blocked)
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+
+		if err := async.Close(ctx); err != context.DeadlineExceeded {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if time.Since(start) > time.Second {
+			t.Fatal("close does not honor the context")
+		}
+
+		<-blocked
+
+		if async.Dropped() != 1 {
+			t.Fatalf("expected 1 dropped event, got %d", async.Dropped())
+		}
+
+		close(release)
+
+		if err := async.Close(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,368 @@
+package talker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrAuditUnavailable is returned by Audit when the Power in the context has no AuditLog.
+	ErrAuditUnavailable = NewError("AUDIT_UNAVAILABLE", "no audit log is configured")
+
+	// ErrAuditTampered is returned by VerifyAudit when the audit trail has been edited or truncated.
+	ErrAuditTampered = NewError("AUDIT_TAMPERED", "audit trail has been tampered with")
+)
+
+const actorContextKey = PowerContextKey("actor_context")
+
+// WithActor adds the actor, e.g. the ID of the signed in admin, to the context.
+// It is recorded in every audit entry made with the returned context.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorContextKey, actor)
+}
+
+// ActorFromContext returns the actor added to the context with WithActor.
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorContextKey).(string)
+
+	return actor
+}
+
+// AuditEntry is a single entry of the audit trail.
+type AuditEntry struct {
+	Seq      int64     `json:"seq"`               // Seq is the position of the entry, starting from 1.
+	Time     time.Time `json:"time"`              // Time is when the entry was recorded.
+	Action   string    `json:"action"`            // Action is the audited action, e.g. "user.delete".
+	Actor    string    `json:"actor,omitempty"`   // Actor is taken from the context, see WithActor.
+	Params   Params    `json:"params,omitempty"`  // Params are given to Audit.
+	Baggage  Params    `json:"baggage,omitempty"` // Baggage is taken from the context, see WithParams.
+	PrevHash string    `json:"prev_hash"`         // PrevHash is the hash of the previous record, empty for the first one.
+}
+
+// AuditRecord is an AuditEntry as it is stored.
+// The hash is the hex encoded SHA-256 of the exact entry bytes, or its HMAC-SHA256 when AuditOptions.Key is set,
+// and every entry contains the hash of the previous record, so editing or removing a record breaks the chain.
+type AuditRecord struct {
+	Entry json.RawMessage `json:"entry"`
+	Hash  string          `json:"hash"`
+}
+
+// Decode decodes the entry of the record.
+func (r AuditRecord) Decode() (AuditEntry, error) {
+	var entry AuditEntry
+
+	err := json.Unmarshal(r.Entry, &entry)
+
+	return entry, err
+}
+
+// AuditStore is an append-only storage for audit records.
+type AuditStore interface {
+	// Append appends the record to the end of the store.
+	Append(ctx context.Context, record AuditRecord) error
+	// Records returns every record in the store, in order.
+	Records(ctx context.Context) ([]AuditRecord, error)
+}
+
+// AuditOptions is the configuration for the NewAuditLogWith and VerifyAuditWith functions.
+type AuditOptions struct {
+	// Key is the HMAC key of the record hashes. Keep it out of the store, e.g. in a secret manager.
+	Key []byte
+}
+
+// AuditLog records hash chained audit entries to an AuditStore.
+// It is added to the Power with WithAuditLog and used by the Audit function.
+//
+// Without a key, the chain detects corruption and edits made by someone who does not recompute the hashes,
+// but anyone who can write the store can edit a record and recompute every later hash.
+// With AuditOptions.Key, records can not be forged or re-hashed without the key, so the trail is tamper-evident
+// against anyone who can write the store but can not read the key; the process holding the key is trusted.
+// In both cases, records removed from the end are only detected with an anchor kept somewhere else, see Head.
+// This struct must be created with the NewAuditLog or NewAuditLogWith function.
+type AuditLog struct {
+	mu    sync.Mutex
+	store AuditStore
+	key   []byte
+	seq   int64
+	head  string
+}
+
+// NewAuditLog creates a new AuditLog that continues the chain of the records already in the store.
+// Example:
+//
+//	store, err := talker.NewFileAuditStore("audit.jsonl")
+//	if err != nil {
+//		return err
+//	}
+//
+//	auditLog, err := talker.NewAuditLog(ctx, store)
+//	if err != nil {
+//		return err
+//	}
+//
+//	pwr := talker.NewPower().WithAuditLog(auditLog)
+func NewAuditLog(ctx context.Context, store AuditStore) (*AuditLog, error) {
+	return NewAuditLogWith(ctx, store, AuditOptions{})
+}
+
+// NewAuditLogWith creates a new AuditLog like NewAuditLog with the given options.
+// Example:
+//
+//	auditLog, err := talker.NewAuditLogWith(ctx, store, talker.AuditOptions{Key: auditKey})
+//	if err != nil {
+//		return err
+//	}
+//
+//	// ... later, e.g. in a scheduled job
+//	err = talker.VerifyAuditWith(ctx, store, &anchor, talker.AuditOptions{Key: auditKey})
+func NewAuditLogWith(ctx context.Context, store AuditStore, opts AuditOptions) (*AuditLog, error) {
+	records, err := store.Records(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	log := &AuditLog{store: store, key: opts.Key}
+
+	if len(records) > 0 {
+		last := records[len(records)-1]
+
+		entry, err := last.Decode()
+		if err != nil {
+			return nil, err
+		}
+
+		log.seq = entry.Seq
+		log.head = last.Hash
+	}
+
+	return log, nil
+}
+
+// Head returns the sequence number and hash of the last record.
+// Keep them somewhere else, so VerifyAudit can detect a truncated trail.
+func (l *AuditLog) Head() AuditAnchor {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return AuditAnchor{Seq: l.seq, Hash: l.head}
+}
+
+// Record appends an entry for the action, with the actor and baggage of the context.
+func (l *AuditLog) Record(ctx context.Context, action string, params Params) (AuditEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry := AuditEntry{
+		Seq:      l.seq + 1,
+		Time:     time.Now().UTC(),
+		Action:   action,
+		Actor:    ActorFromContext(ctx),
+		Params:   params,
+		Baggage:  ParamsFromContext(ctx),
+		PrevHash: l.head,
+	}
+
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return entry, err
+	}
+
+	record := AuditRecord{Entry: raw, Hash: auditHash(l.key, raw)}
+
+	if err := l.store.Append(ctx, record); err != nil {
+		return entry, err
+	}
+
+	l.seq = entry.Seq
+	l.head = record.Hash
+
+	return entry, nil
+}
+
+func auditHash(key []byte, raw []byte) string {
+	if len(key) == 0 {
+		sum := sha256.Sum256(raw)
+
+		return hex.EncodeToString(sum[:])
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write(raw)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Audit records the action in the AuditLog of the Power in the context,
+// and sends an "audit" event with the action, actor and sequence number.
+// It returns ErrAuditUnavailable when there is no AuditLog.
+// Example:
+//
+//	func deleteUser(ctx context.Context, id string) error {
+//		// ... delete the user
+//		return talker.Audit(talker.WithActor(ctx, admin.ID), "user.delete", talker.Params{"user_id": id})
+//	}
+func Audit(ctx context.Context, action string, params Params) error {
+	pwr, _ := ctx.Value(powerContextKey).(Power)
+	if pwr.auditLog == nil {
+		return ErrAuditUnavailable
+	}
+
+	entry, err := pwr.auditLog.Record(ctx, action, params)
+	if err != nil {
+		return err
+	}
+
+	Event(ctx, "audit", Params{"action": entry.Action, "actor": entry.Actor, "seq": entry.Seq})
+
+	return nil
+}
+
+// AuditAnchor is a known position in the audit trail, see AuditLog.Head.
+type AuditAnchor struct {
+	Seq  int64  `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+// VerifyAudit checks the hash chain of every record in the store.
+// Edited, removed or reordered records are always detected. Records removed from the end are
+// only detected when an anchor is given, it is the Head of the log at some earlier point in time.
+// It returns ErrAuditTampered describing the first broken record.
+// Use VerifyAuditWith for a log created with a key, check out AuditLog for what is detected.
+func VerifyAudit(ctx context.Context, store AuditStore, anchor *AuditAnchor) error {
+	return VerifyAuditWith(ctx, store, anchor, AuditOptions{})
+}
+
+// VerifyAuditWith checks the hash chain like VerifyAudit, with the options the log was created with.
+func VerifyAuditWith(ctx context.Context, store AuditStore, anchor *AuditAnchor, opts AuditOptions) error {
+	records, err := store.Records(ctx)
+	if err != nil {
+		return err
+	}
+
+	prev := ""
+
+	for i, record := range records {
+		seq := int64(i + 1)
+
+		if !hmac.Equal([]byte(auditHash(opts.Key, record.Entry)), []byte(record.Hash)) {
+			return ErrAuditTampered.WithInfo(fmt.Sprintf("audit record %d does not match its hash", seq))
+		}
+
+		entry, err := record.Decode()
+		if err != nil {
+			return ErrAuditTampered.Wrap(err).WithInfo(fmt.Sprintf("audit record %d can not be decoded", seq))
+		}
+
+		if entry.Seq != seq || entry.PrevHash != prev {
+			return ErrAuditTampered.WithInfo(fmt.Sprintf("audit record %d is not chained to the previous record", seq))
+		}
+
+		if anchor != nil && entry.Seq == anchor.Seq && record.Hash != anchor.Hash {
+			return ErrAuditTampered.WithInfo(fmt.Sprintf("audit record %d does not match the anchor", seq))
+		}
+
+		prev = record.Hash
+	}
+
+	if anchor != nil && int64(len(records)) < anchor.Seq {
+		return ErrAuditTampered.WithInfo(fmt.Sprintf("audit trail is truncated at record %d, expected at least %d", len(records), anchor.Seq))
+	}
+
+	return nil
+}
+
+// FileAuditStore is an AuditStore that appends records as JSON lines to a local file.
+// This struct must be created with the NewFileAuditStore function.
+type FileAuditStore struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+var _ AuditStore = &FileAuditStore{}
+
+// NewFileAuditStore opens or creates the JSONL file at the given path.
+func NewFileAuditStore(path string) (*FileAuditStore, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FileAuditStore{path: path, file: file}, nil
+}
+
+// Append writes the record as a line and syncs the file.
+func (s *FileAuditStore) Append(ctx context.Context, record AuditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+
+	return s.file.Sync()
+}
+
+// Records reads every record from the file.
+// A last line without a line break, e.g. after a partial write, is reported as ErrAuditTampered.
+func (s *FileAuditStore) Records(ctx context.Context) ([]AuditRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	records := []AuditRecord{}
+	reader := bufio.NewReader(f)
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(bytes.TrimSpace(line)) > 0 {
+				return nil, ErrAuditTampered.WithInfo(fmt.Sprintf("audit record %d is incomplete", len(records)+1))
+			}
+
+			return records, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		var record AuditRecord
+
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, ErrAuditTampered.Wrap(err).WithInfo(fmt.Sprintf("audit record %d can not be decoded", len(records)+1))
+		}
+
+		records = append(records, record)
+	}
+}
+
+// Close closes the file.
+func (s *FileAuditStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
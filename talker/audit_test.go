@@ -0,0 +1,203 @@
+package talker_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Arsfiqball/csverse/talker"
+)
+
+func TestAudit(t *testing.T) {
+	setup := func(t *testing.T) (string, context.Context, *talker.AuditLog) {
+		path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+		store, err := talker.NewFileAuditStore(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Cleanup(func() { store.Close() })
+
+		log, err := talker.NewAuditLog(context.Background(), store)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx := talker.NewPower().WithAuditLog(log).Context(context.Background(), "test")
+		ctx = talker.WithActor(ctx, "admin-1")
+		ctx = talker.WithParams(ctx, talker.Params{"tenant_id": "t1"})
+
+		for _, action := range []string{"user.create", "user.update", "user.delete"} {
+			if err := talker.Audit(ctx, action, talker.Params{"user_id": "u1"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		return path, ctx, log
+	}
+
+	open := func(t *testing.T, path string) *talker.FileAuditStore {
+		store, err := talker.NewFileAuditStore(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Cleanup(func() { store.Close() })
+
+		return store
+	}
+
+	t.Run("keyed", func(t *testing.T) {
+		key := []byte("secret")
+		path := filepath.Join(t.TempDir(), "audit.jsonl")
+		store := open(t, path)
+
+		log, err := talker.NewAuditLogWith(context.Background(), store, talker.AuditOptions{Key: key})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := log.Record(context.Background(), "user.delete", talker.Params{"user_id": "u1"}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := talker.VerifyAuditWith(context.Background(), store, nil, talker.AuditOptions{Key: key}); err != nil {
+			t.Fatal(err)
+		}
+
+		// A forged trail with recomputed hashes passes without the key only.
+		forgedPath := filepath.Join(t.TempDir(), "audit.jsonl")
+		forged := open(t, forgedPath)
+
+		forger, err := talker.NewAuditLog(context.Background(), forged)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := forger.Record(context.Background(), "user.noop", talker.Params{"user_id": "u1"}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := talker.VerifyAudit(context.Background(), forged, nil); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := talker.VerifyAuditWith(context.Background(), forged, nil, talker.AuditOptions{Key: key}); !errors.Is(err, talker.ErrAuditTampered) {
+			t.Fatal("forged trail is not detected")
+		}
+	})
+
+	t.Run("unavailable", func(t *testing.T) {
+		if err := talker.Audit(context.Background(), "user.delete", nil); !errors.Is(err, talker.ErrAuditUnavailable) {
+			t.Fatal("missing audit log is not reported")
+		}
+	})
+
+	t.Run("verify", func(t *testing.T) {
+		path, _, log := setup(t)
+		store := open(t, path)
+		anchor := log.Head()
+
+		if err := talker.VerifyAudit(context.Background(), store, &anchor); err != nil {
+			t.Fatal(err)
+		}
+
+		records, err := store.Records(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		entry, err := records[2].Decode()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if entry.Seq != 3 || entry.Actor != "admin-1" || entry.Baggage["tenant_id"] != "t1" || entry.PrevHash != records[1].Hash {
+			t.Fatalf("unexpected entry: %+v", entry)
+		}
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		path, _, _ := setup(t)
+		store := open(t, path)
+
+		log, err := talker.NewAuditLog(context.Background(), store)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := log.Record(context.Background(), "user.restore", nil); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := talker.VerifyAudit(context.Background(), store, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("edited", func(t *testing.T) {
+		path, _, _ := setup(t)
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, bytes.Replace(b, []byte("user.update"), []byte("user.noop"), 1), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := talker.VerifyAudit(context.Background(), open(t, path), nil); !errors.Is(err, talker.ErrAuditTampered) {
+			t.Fatal("edited record is not detected")
+		}
+	})
+
+	t.Run("removed", func(t *testing.T) {
+		path, _, _ := setup(t)
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lines := bytes.SplitAfter(b, []byte("\n"))
+		if err := os.WriteFile(path, append(lines[0], lines[2]...), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := talker.VerifyAudit(context.Background(), open(t, path), nil); !errors.Is(err, talker.ErrAuditTampered) {
+			t.Fatal("removed record is not detected")
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		path, _, log := setup(t)
+		anchor := log.Head()
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lines := bytes.SplitAfter(b, []byte("\n"))
+		if err := os.WriteFile(path, append(lines[0], lines[1]...), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := talker.VerifyAudit(context.Background(), open(t, path), &anchor); !errors.Is(err, talker.ErrAuditTampered) {
+			t.Fatal("truncated trail is not detected")
+		}
+
+		if err := os.WriteFile(path, append(append(lines[0], lines[1]...), lines[2][:10]...), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := talker.VerifyAudit(context.Background(), open(t, path), nil); !errors.Is(err, talker.ErrAuditTampered) {
+			t.Fatal("partial record is not detected")
+		}
+	})
+}
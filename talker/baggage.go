@@ -0,0 +1,114 @@
+package talker
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+)
+
+const paramsContextKey = PowerContextKey("params_context")
+
+// WithParams adds request scoped params to the context.
+// Every Span and Event in the returned context receives these params, merged with the params given to them.
+// Params given to Span or Event take precedence over the params in the context.
+// Example:
+//
+//	func handle(ctx context.Context, req Request) {
+//		ctx = talker.WithParams(ctx, talker.Params{"tenant_id": req.TenantID, "request_id": req.ID})
+//		ctx, end := talker.Span(ctx, "handle", nil) // receives tenant_id and request_id
+//		defer end()
+//	}
+func WithParams(ctx context.Context, params Params) context.Context {
+	parent := ParamsFromContext(ctx)
+	merged := make(Params, len(parent)+len(params))
+
+	for key, value := range parent {
+		merged[key] = value
+	}
+
+	for key, value := range params {
+		merged[key] = value
+	}
+
+	return context.WithValue(ctx, paramsContextKey, merged)
+}
+
+// ParamsFromContext returns the params added to the context with WithParams.
+// The returned params must not be modified.
+func ParamsFromContext(ctx context.Context) Params {
+	params, _ := ctx.Value(paramsContextKey).(Params)
+
+	return params
+}
+
+func mergeParams(ctx context.Context, params map[string]any) map[string]any {
+	baggage := ParamsFromContext(ctx)
+	if len(baggage) == 0 {
+		return params
+	}
+
+	merged := make(map[string]any, len(baggage)+len(params))
+
+	for key, value := range baggage {
+		merged[key] = value
+	}
+
+	for key, value := range params {
+		merged[key] = value
+	}
+
+	return merged
+}
+
+type paramsHandler struct {
+	handler slog.Handler
+}
+
+var _ slog.Handler = paramsHandler{}
+
+// NewParamsHandler wraps the slog.Handler, so the params added to the context with WithParams
+// are added to every log record that is logged with a context.
+// Example:
+//
+//	logger := slog.New(talker.NewParamsHandler(slog.NewJSONHandler(os.Stdout, nil)))
+//	logger.InfoContext(ctx, "something happened") // includes tenant_id, request_id, etc.
+func NewParamsHandler(handler slog.Handler) slog.Handler {
+	return paramsHandler{handler: handler}
+}
+
+// Enabled reports whether the wrapped handler handles records at the given level.
+func (h paramsHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+// Handle adds the params in the context to the record and passes it to the wrapped handler.
+func (h paramsHandler) Handle(ctx context.Context, record slog.Record) error {
+	params := ParamsFromContext(ctx)
+
+	if len(params) > 0 {
+		keys := make([]string, 0, len(params))
+		for key := range params {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		record = record.Clone()
+
+		for _, key := range keys {
+			record.AddAttrs(slog.Any(key, params[key]))
+		}
+	}
+
+	return h.handler.Handle(ctx, record)
+}
+
+// WithAttrs returns a new handler with the given attributes.
+func (h paramsHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return paramsHandler{handler: h.handler.WithAttrs(attrs)}
+}
+
+// WithGroup returns a new handler with the given group.
+func (h paramsHandler) WithGroup(name string) slog.Handler {
+	return paramsHandler{handler: h.handler.WithGroup(name)}
+}
@@ -0,0 +1,55 @@
+package talker_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/Arsfiqball/csverse/talker"
+)
+
+func TestWithParams(t *testing.T) {
+	t.Run("span and event", func(t *testing.T) {
+		var spanParams, eventParams map[string]any
+
+		pwr := talker.NewPower().
+			WithSpanHook(func(ctx context.Context, name string, attrs map[string]any) (context.Context, func()) {
+				spanParams = attrs
+				return ctx, func() {}
+			}).
+			WithEventHook(func(ctx context.Context, name string, attrs map[string]any) {
+				eventParams = attrs
+			})
+
+		ctx := pwr.Context(context.Background(), "test")
+		ctx = talker.WithParams(ctx, talker.Params{"tenant_id": "t1", "user_id": "u1"})
+		ctx = talker.WithParams(ctx, talker.Params{"request_id": "r1"})
+
+		ctx, end := talker.Span(ctx, "work", talker.Params{"user_id": "u2"})
+		talker.Event(ctx, "done", nil)
+		end()
+
+		if spanParams["tenant_id"] != "t1" || spanParams["request_id"] != "r1" || spanParams["user_id"] != "u2" {
+			t.Fatalf("unexpected span params: %v", spanParams)
+		}
+
+		if eventParams["tenant_id"] != "t1" || eventParams["user_id"] != "u1" {
+			t.Fatalf("unexpected event params: %v", eventParams)
+		}
+	})
+
+	t.Run("slog", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		logger := slog.New(talker.NewParamsHandler(slog.NewTextHandler(&buf, nil)))
+		ctx := talker.WithParams(context.Background(), talker.Params{"tenant_id": "t1"})
+
+		logger.InfoContext(ctx, "hello", "key", "value")
+
+		if !strings.Contains(buf.String(), "key=value tenant_id=t1") {
+			t.Fatalf("params are not logged: %s", buf.String())
+		}
+	})
+}
@@ -0,0 +1,421 @@
+package talker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrBreakerOpen is the error returned when a call is rejected by a circuit breaker,
+// the data of the error is the BreakerStatus at the time of the rejection.
+var ErrBreakerOpen = NewError("BREAKER_OPEN", "circuit breaker is open")
+
+// BreakerState is the state of a circuit breaker.
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // BreakerClosed lets every call through.
+	BreakerOpen                         // BreakerOpen rejects every call until the cooldown is over.
+	BreakerHalfOpen                     // BreakerHalfOpen lets a few trial calls through to decide whether to close or open again.
+)
+
+// String returns the name of the state.
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// MarshalJSON writes the state as its name.
+func (s BreakerState) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// BreakerOptions is the configuration for the CircuitBreaker function.
+// When neither ConsecutiveFailures nor FailureRate is set, the breaker opens after 5 consecutive failures.
+type BreakerOptions struct {
+	Name                string               // Name shares the breaker across calls, through the DefaultBreakerRegistry.
+	ConsecutiveFailures int                  // ConsecutiveFailures opens the breaker after this many failures in a row, disabled when it is 0.
+	FailureRate         float64              // FailureRate opens the breaker when the failure rate in the window reaches it (0 to 1), disabled when it is 0.
+	MinRequests         int                  // MinRequests is the minimum number of calls in the window before FailureRate is checked, default is 10.
+	Window              time.Duration        // Window is the rolling window used by FailureRate, default is 1 minute.
+	Cooldown            time.Duration        // Cooldown is how long the breaker stays open before going half-open, default is 30 seconds.
+	HalfOpenRequests    int                  // HalfOpenRequests is the number of successful trial calls needed to close the breaker, default is 1.
+	IsFailure           func(err error) bool // IsFailure decides whether an error counts as a failure, check out Breaker.Wrap for the default.
+}
+
+func (o BreakerOptions) sanitize() BreakerOptions {
+	if o.ConsecutiveFailures <= 0 && o.FailureRate <= 0 {
+		o.ConsecutiveFailures = 5
+	}
+
+	if o.MinRequests <= 0 {
+		o.MinRequests = 10
+	}
+
+	if o.Window <= 0 {
+		o.Window = time.Minute
+	}
+
+	if o.Cooldown <= 0 {
+		o.Cooldown = 30 * time.Second
+	}
+
+	if o.HalfOpenRequests <= 0 {
+		o.HalfOpenRequests = 1
+	}
+
+	if o.IsFailure == nil {
+		o.IsFailure = func(err error) bool { return err != nil }
+	}
+
+	return o
+}
+
+// BreakerStatus is a snapshot of a circuit breaker.
+type BreakerStatus struct {
+	Name                string       `json:"name"`
+	State               BreakerState `json:"state"`
+	Requests            int          `json:"requests"`             // Requests is the number of calls in the rolling window.
+	Failures            int          `json:"failures"`             // Failures is the number of failed calls in the rolling window.
+	ConsecutiveFailures int          `json:"consecutive_failures"` // ConsecutiveFailures is the number of failures in a row.
+	OpenedAt            time.Time    `json:"opened_at"`            // OpenedAt is when the breaker was last opened.
+}
+
+// breakerBuckets is the number of buckets of the rolling window.
+const breakerBuckets = 10
+
+type breakerBucket struct {
+	epoch    int64
+	requests int
+	failures int
+}
+
+// Breaker is a circuit breaker that can wrap many callbacks.
+// This struct must be created with the NewBreaker function or BreakerRegistry.Breaker.
+type Breaker struct {
+	name          string
+	opts          BreakerOptions
+	ignoreCallers bool // ignoreCallers ignores the calls ended by the caller's own context, with the default IsFailure.
+
+	mu          sync.Mutex
+	state       BreakerState
+	openedAt    time.Time
+	consecutive int
+	buckets     [breakerBuckets]breakerBucket
+	trials      int // trial calls started in the half-open state
+	successes   int // successful trial calls in the half-open state
+	changes     []Params
+}
+
+// NewBreaker creates a new Breaker that is not shared.
+func NewBreaker(opts BreakerOptions) *Breaker {
+	return &Breaker{name: opts.Name, opts: opts.sanitize(), ignoreCallers: opts.IsFailure == nil}
+}
+
+// Wrap returns a Callback that runs callback through the breaker.
+// When the breaker is open, callback is not run and ErrBreakerOpen is returned.
+// State changes are sent as "breaker.state_change" events with the "breaker", "from" and "to" params.
+// Without BreakerOptions.IsFailure, every error counts as a failure, except the error of the caller's own context:
+// a caller that gave up says nothing about the health of the dependency, so the call is ignored.
+func (b *Breaker) Wrap(callback Callback) Callback {
+	return func(ctx context.Context) error {
+		trial, err := b.allow(ctx)
+		if err != nil {
+			return err
+		}
+
+		err = callback(ctx)
+
+		if b.ignoreCallers && err != nil && ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+			b.ignore(trial)
+			return err
+		}
+
+		b.done(ctx, trial, b.opts.IsFailure(err))
+
+		return err
+	}
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() BreakerState {
+	return b.Status().State
+}
+
+// Status returns a snapshot of the breaker.
+func (b *Breaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.status(time.Now())
+}
+
+func (b *Breaker) status(now time.Time) BreakerStatus {
+	state := b.state
+	if state == BreakerOpen && now.Sub(b.openedAt) >= b.opts.Cooldown {
+		state = BreakerHalfOpen
+	}
+
+	requests, failures := b.window(now)
+
+	return BreakerStatus{
+		Name:                b.name,
+		State:               state,
+		Requests:            requests,
+		Failures:            failures,
+		ConsecutiveFailures: b.consecutive,
+		OpenedAt:            b.openedAt,
+	}
+}
+
+func (b *Breaker) allow(ctx context.Context) (bool, error) {
+	defer b.flush(ctx)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+
+	if b.state == BreakerOpen && now.Sub(b.openedAt) >= b.opts.Cooldown {
+		b.setState(BreakerHalfOpen)
+	}
+
+	switch b.state {
+	case BreakerOpen:
+		return false, b.rejection(now)
+	case BreakerHalfOpen:
+		if b.trials >= b.opts.HalfOpenRequests {
+			return false, b.rejection(now)
+		}
+
+		b.trials++
+
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+func (b *Breaker) rejection(now time.Time) error {
+	return ErrBreakerOpen.
+		WithInfo(fmt.Sprintf("circuit breaker %s is %s", b.name, b.state)).
+		WithData(b.status(now))
+}
+
+// ignore releases the trial of an ignored call, so another trial call can be made.
+func (b *Breaker) ignore(trial bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if trial && b.state == BreakerHalfOpen && b.trials > 0 {
+		b.trials--
+	}
+}
+
+func (b *Breaker) done(ctx context.Context, trial bool, failed bool) {
+	defer b.flush(ctx)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+
+	if trial {
+		if b.state != BreakerHalfOpen {
+			return // the state was changed by another trial call
+		}
+
+		if failed {
+			b.open(now)
+			return
+		}
+
+		b.successes++
+
+		if b.successes >= b.opts.HalfOpenRequests {
+			b.consecutive = 0
+			b.buckets = [breakerBuckets]breakerBucket{}
+			b.setState(BreakerClosed)
+		}
+
+		return
+	}
+
+	if b.state != BreakerClosed {
+		return
+	}
+
+	bucket := b.bucket(now)
+	bucket.requests++
+
+	if !failed {
+		b.consecutive = 0
+		return
+	}
+
+	bucket.failures++
+	b.consecutive++
+
+	if b.opts.ConsecutiveFailures > 0 && b.consecutive >= b.opts.ConsecutiveFailures {
+		b.open(now)
+		return
+	}
+
+	if b.opts.FailureRate > 0 {
+		requests, failures := b.window(now)
+
+		if requests >= b.opts.MinRequests && float64(failures)/float64(requests) >= b.opts.FailureRate {
+			b.open(now)
+		}
+	}
+}
+
+func (b *Breaker) open(now time.Time) {
+	b.openedAt = now
+	b.setState(BreakerOpen)
+}
+
+func (b *Breaker) setState(state BreakerState) {
+	from := b.state
+	b.state = state
+	b.trials = 0
+	b.successes = 0
+	b.changes = append(b.changes, Params{"breaker": b.name, "from": from.String(), "to": state.String()})
+}
+
+// flush sends the state changes as events, outside of the lock, so hooks can use the breaker.
+func (b *Breaker) flush(ctx context.Context) {
+	b.mu.Lock()
+	changes := b.changes
+	b.changes = nil
+	b.mu.Unlock()
+
+	for _, change := range changes {
+		Event(ctx, "breaker.state_change", change)
+	}
+}
+
+func (b *Breaker) epoch(now time.Time) int64 {
+	return now.UnixNano() / int64(b.opts.Window/breakerBuckets)
+}
+
+func (b *Breaker) bucket(now time.Time) *breakerBucket {
+	epoch := b.epoch(now)
+	bucket := &b.buckets[epoch%breakerBuckets]
+
+	if bucket.epoch != epoch {
+		*bucket = breakerBucket{epoch: epoch}
+	}
+
+	return bucket
+}
+
+func (b *Breaker) window(now time.Time) (int, int) {
+	epoch := b.epoch(now)
+	requests, failures := 0, 0
+
+	for _, bucket := range b.buckets {
+		if epoch-bucket.epoch < breakerBuckets {
+			requests += bucket.requests
+			failures += bucket.failures
+		}
+	}
+
+	return requests, failures
+}
+
+// BreakerRegistry keeps circuit breakers by name, so they can be shared across calls.
+// It can be served by the monitor server with Process.Breakers.
+// This struct must be created with the NewBreakerRegistry function.
+type BreakerRegistry struct {
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+// DefaultBreakerRegistry is the registry used by CircuitBreaker for named breakers.
+var DefaultBreakerRegistry = NewBreakerRegistry()
+
+// NewBreakerRegistry creates a new BreakerRegistry.
+func NewBreakerRegistry() *BreakerRegistry {
+	return &BreakerRegistry{breakers: map[string]*Breaker{}}
+}
+
+// Breaker returns the breaker with the given name, it is created with opts when it does not exist yet.
+// The options of an existing breaker are not changed.
+func (r *BreakerRegistry) Breaker(name string, opts BreakerOptions) *Breaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if b, ok := r.breakers[name]; ok {
+		return b
+	}
+
+	opts.Name = name
+	b := NewBreaker(opts)
+	r.breakers[name] = b
+
+	return b
+}
+
+// Statuses returns the status of every breaker, sorted by name.
+func (r *BreakerRegistry) Statuses() []BreakerStatus {
+	r.mu.Lock()
+	breakers := make([]*Breaker, 0, len(r.breakers))
+	for _, b := range r.breakers {
+		breakers = append(breakers, b)
+	}
+	r.mu.Unlock()
+
+	statuses := make([]BreakerStatus, 0, len(breakers))
+	for _, b := range breakers {
+		statuses = append(statuses, b.Status())
+	}
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+
+	return statuses
+}
+
+// ServeHTTP writes the status of every breaker as JSON.
+func (r *BreakerRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(r.Statuses())
+}
+
+// CircuitBreaker runs callback through a circuit breaker.
+// When opts.Name is set, the breaker is shared with every CircuitBreaker of the same name,
+// through the DefaultBreakerRegistry, otherwise the breaker belongs to the returned Callback.
+// Example:
+//
+//	charge := talker.CircuitBreaker(
+//		func(ctx context.Context) error {
+//			// ... call the payment provider
+//			return nil
+//		},
+//		talker.BreakerOptions{
+//			Name:        "payments",
+//			FailureRate: 0.5,
+//			Window:      time.Minute,
+//			Cooldown:    10 * time.Second,
+//		},
+//	)
+//
+//	err := charge(ctx) // errors.Is(err, talker.ErrBreakerOpen) when the breaker is open
+func CircuitBreaker(callback Callback, opts BreakerOptions) Callback {
+	if opts.Name != "" {
+		return DefaultBreakerRegistry.Breaker(opts.Name, opts).Wrap(callback)
+	}
+
+	return NewBreaker(opts).Wrap(callback)
+}
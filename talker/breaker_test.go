@@ -0,0 +1,199 @@
+package talker_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Arsfiqball/csverse/talker"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	errFailed := errors.New("failed")
+	fail := func(ctx context.Context) error { return errFailed }
+	succeed := func(ctx context.Context) error { return nil }
+
+	t.Run("consecutive failures", func(t *testing.T) {
+		b := talker.NewBreaker(talker.BreakerOptions{ConsecutiveFailures: 3, Cooldown: time.Hour})
+		cb := b.Wrap(fail)
+
+		for i := 0; i < 3; i++ {
+			if err := cb(context.Background()); !errors.Is(err, errFailed) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		if b.State() != talker.BreakerOpen {
+			t.Fatalf("unexpected state: %s", b.State())
+		}
+
+		err := cb(context.Background())
+		if !errors.Is(err, talker.ErrBreakerOpen) {
+			t.Fatalf("call is not rejected: %v", err)
+		}
+
+		var breakerErr talker.Error
+		if !errors.As(err, &breakerErr) || breakerErr.Data().(talker.BreakerStatus).State != talker.BreakerOpen {
+			t.Fatal("status is not set as data")
+		}
+	})
+
+	t.Run("success resets consecutive failures", func(t *testing.T) {
+		b := talker.NewBreaker(talker.BreakerOptions{ConsecutiveFailures: 2})
+
+		_ = b.Wrap(fail)(context.Background())
+		_ = b.Wrap(succeed)(context.Background())
+		_ = b.Wrap(fail)(context.Background())
+
+		if b.State() != talker.BreakerClosed {
+			t.Fatalf("unexpected state: %s", b.State())
+		}
+	})
+
+	t.Run("caller cancellation", func(t *testing.T) {
+		b := talker.NewBreaker(talker.BreakerOptions{ConsecutiveFailures: 1, Cooldown: time.Hour})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := b.Wrap(func(ctx context.Context) error { return ctx.Err() })(ctx)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if status := b.Status(); status.State != talker.BreakerClosed || status.Requests != 0 {
+			t.Fatalf("cancelled call is counted: %+v", status)
+		}
+
+		// The dependency timing out on its own is still a failure.
+		_ = b.Wrap(func(ctx context.Context) error { return context.DeadlineExceeded })(context.Background())
+
+		if b.State() != talker.BreakerOpen {
+			t.Fatalf("unexpected state: %s", b.State())
+		}
+	})
+
+	t.Run("failure rate", func(t *testing.T) {
+		b := talker.NewBreaker(talker.BreakerOptions{FailureRate: 0.5, MinRequests: 4})
+
+		_ = b.Wrap(fail)(context.Background())
+		_ = b.Wrap(fail)(context.Background())
+
+		if b.State() != talker.BreakerClosed {
+			t.Fatal("breaker is opened before MinRequests")
+		}
+
+		_ = b.Wrap(succeed)(context.Background())
+		_ = b.Wrap(fail)(context.Background())
+
+		if b.State() != talker.BreakerOpen {
+			t.Fatalf("unexpected state: %s", b.State())
+		}
+	})
+
+	t.Run("rolling window", func(t *testing.T) {
+		b := talker.NewBreaker(talker.BreakerOptions{FailureRate: 0.5, MinRequests: 2, Window: 50 * time.Millisecond})
+
+		_ = b.Wrap(fail)(context.Background())
+		time.Sleep(60 * time.Millisecond)
+
+		if status := b.Status(); status.Requests != 0 {
+			t.Fatalf("old calls are still in the window: %+v", status)
+		}
+
+		_ = b.Wrap(succeed)(context.Background())
+		_ = b.Wrap(fail)(context.Background())
+
+		if b.State() != talker.BreakerOpen {
+			t.Fatalf("unexpected state: %s", b.State())
+		}
+	})
+
+	t.Run("half open", func(t *testing.T) {
+		events := &eventRecorder{}
+		ctx := talker.NewPower().WithEventHook(events.hook).Context(context.Background(), "test")
+
+		b := talker.NewBreaker(talker.BreakerOptions{Name: "payments", ConsecutiveFailures: 1, Cooldown: 20 * time.Millisecond})
+
+		_ = b.Wrap(fail)(ctx)
+		time.Sleep(30 * time.Millisecond)
+
+		if b.State() != talker.BreakerHalfOpen {
+			t.Fatalf("unexpected state: %s", b.State())
+		}
+
+		// A failed trial call opens the breaker again.
+		_ = b.Wrap(fail)(ctx)
+
+		if b.State() != talker.BreakerOpen {
+			t.Fatalf("unexpected state: %s", b.State())
+		}
+
+		time.Sleep(30 * time.Millisecond)
+
+		release := make(chan struct{})
+		done := make(chan error)
+
+		go func() {
+			done <- b.Wrap(func(ctx context.Context) error {
+				<-release
+				return nil
+			})(ctx)
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+
+		if err := b.Wrap(succeed)(ctx); !errors.Is(err, talker.ErrBreakerOpen) {
+			t.Fatal("second trial call is not rejected")
+		}
+
+		close(release)
+
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+
+		if b.State() != talker.BreakerClosed {
+			t.Fatalf("unexpected state: %s", b.State())
+		}
+
+		e, ok := events.find("breaker.state_change")
+		if !ok || e.attrs["breaker"] != "payments" || e.attrs["from"] != "closed" || e.attrs["to"] != "open" {
+			t.Fatal("state change event is not sent")
+		}
+	})
+
+	t.Run("shared by name", func(t *testing.T) {
+		opts := talker.BreakerOptions{Name: "test.shared", ConsecutiveFailures: 1, Cooldown: time.Hour}
+
+		_ = talker.CircuitBreaker(fail, opts)(context.Background())
+
+		if err := talker.CircuitBreaker(succeed, opts)(context.Background()); !errors.Is(err, talker.ErrBreakerOpen) {
+			t.Fatal("breaker is not shared")
+		}
+	})
+}
+
+func TestBreakerRegistry(t *testing.T) {
+	registry := talker.NewBreakerRegistry()
+
+	registry.Breaker("b", talker.BreakerOptions{})
+	_ = registry.Breaker("a", talker.BreakerOptions{ConsecutiveFailures: 1}).Wrap(func(ctx context.Context) error {
+		return errors.New("failed")
+	})(context.Background())
+
+	rec := httptest.NewRecorder()
+	registry.ServeHTTP(rec, httptest.NewRequest("GET", "/breakers", nil))
+
+	var statuses []map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &statuses); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(statuses) != 2 || statuses[0]["name"] != "a" || statuses[0]["state"] != "open" || statuses[1]["state"] != "closed" {
+		t.Fatalf("unexpected statuses: %s", rec.Body.String())
+	}
+}
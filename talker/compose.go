@@ -0,0 +1,255 @@
+package talker
+
+import (
+	"context"
+	"log/slog"
+	"path"
+	"strings"
+)
+
+// NameMatcher reports whether a span, event or metric name matches.
+type NameMatcher func(name string) bool
+
+// MatchGlob returns a NameMatcher for the glob pattern, using the syntax of path.Match, e.g. "http.*".
+// An invalid pattern matches nothing.
+func MatchGlob(pattern string) NameMatcher {
+	return func(name string) bool {
+		ok, err := path.Match(pattern, name)
+
+		return err == nil && ok
+	}
+}
+
+// MatchPrefix returns a NameMatcher for names that start with the prefix.
+func MatchPrefix(prefix string) NameMatcher {
+	return func(name string) bool {
+		return strings.HasPrefix(name, prefix)
+	}
+}
+
+// FilterSpanHook returns a SpanHook that only calls hook for the span names that match.
+func FilterSpanHook(match NameMatcher, hook SpanHook) SpanHook {
+	return func(ctx context.Context, name string, attrs map[string]any) (context.Context, func()) {
+		if !match(name) {
+			return ctx, noopEnd
+		}
+
+		return hook(ctx, name, attrs)
+	}
+}
+
+// FilterEventHook returns an EventHook that only calls hook for the event names that match.
+func FilterEventHook(match NameMatcher, hook EventHook) EventHook {
+	return func(ctx context.Context, name string, attrs map[string]any) {
+		if match(name) {
+			hook(ctx, name, attrs)
+		}
+	}
+}
+
+// FilterMetricHook returns a MetricHook that only calls hook for the metric names that match.
+func FilterMetricHook(match NameMatcher, hook MetricHook) MetricHook {
+	return func(ctx context.Context, kind MetricKind, name string, value float64, attrs map[string]any) {
+		if match(name) {
+			hook(ctx, kind, name, value, attrs)
+		}
+	}
+}
+
+// Merge returns a Power with the hooks of c followed by the hooks of the others.
+// The samplers, resource and audit log of c are kept, those of the others are only used when c has none.
+// Example:
+//
+//	pwr := tracing.Merge(metrics, logging)
+func (c Power) Merge(others ...Power) Power {
+	merged := c
+
+	merged.spanHooks = append([]SpanHook{}, c.spanHooks...)
+	merged.eventHooks = append([]EventHook{}, c.eventHooks...)
+	merged.metricHooks = append([]MetricHook{}, c.metricHooks...)
+	merged.spanParamsHooks = append([]SpanParamsHook{}, c.spanParamsHooks...)
+	merged.spanErrorHooks = append([]SpanErrorHook{}, c.spanErrorHooks...)
+	merged.spanLinkHooks = append([]SpanLinkHook{}, c.spanLinkHooks...)
+
+	for _, other := range others {
+		merged.spanHooks = append(merged.spanHooks, other.spanHooks...)
+		merged.eventHooks = append(merged.eventHooks, other.eventHooks...)
+		merged.metricHooks = append(merged.metricHooks, other.metricHooks...)
+		merged.spanParamsHooks = append(merged.spanParamsHooks, other.spanParamsHooks...)
+		merged.spanErrorHooks = append(merged.spanErrorHooks, other.spanErrorHooks...)
+		merged.spanLinkHooks = append(merged.spanLinkHooks, other.spanLinkHooks...)
+		merged.combinatorSpans = merged.combinatorSpans || other.combinatorSpans
+
+		if merged.spanSampler == nil {
+			merged.spanSampler = other.spanSampler
+		}
+
+		if merged.eventSampler == nil {
+			merged.eventSampler = other.eventSampler
+		}
+
+		if merged.resource.ServiceName == "" {
+			merged.resource = other.resource
+		}
+
+		if merged.auditLog == nil {
+			merged.auditLog = other.auditLog
+		}
+
+		if merged.hookPanicHandler == nil {
+			merged.hookPanicHandler = other.hookPanicHandler
+		}
+	}
+
+	return merged
+}
+
+// Route returns a Power with the hooks of sub added, but only called for the span, event and metric names that match.
+// The span params, error and link hooks of sub are called for the spans with a matching name.
+// Example:
+//
+//	pwr := talker.NewPower().
+//		WithEventHook(logHook).
+//		Route(talker.MatchPrefix("billing."), billingPower) // billing hooks only see billing spans and events
+func (c Power) Route(match NameMatcher, sub Power) Power {
+	routed := Power{}
+
+	for _, hook := range sub.spanHooks {
+		routed.spanHooks = append(routed.spanHooks, FilterSpanHook(match, hook))
+	}
+
+	for _, hook := range sub.eventHooks {
+		routed.eventHooks = append(routed.eventHooks, FilterEventHook(match, hook))
+	}
+
+	for _, hook := range sub.metricHooks {
+		routed.metricHooks = append(routed.metricHooks, FilterMetricHook(match, hook))
+	}
+
+	for _, hook := range sub.spanParamsHooks {
+		hook := hook
+		routed.spanParamsHooks = append(routed.spanParamsHooks, func(ctx context.Context, name string, params map[string]any) {
+			if match(name) {
+				hook(ctx, name, params)
+			}
+		})
+	}
+
+	for _, hook := range sub.spanErrorHooks {
+		hook := hook
+		routed.spanErrorHooks = append(routed.spanErrorHooks, func(ctx context.Context, name string, err error) {
+			if match(name) {
+				hook(ctx, name, err)
+			}
+		})
+	}
+
+	for _, hook := range sub.spanLinkHooks {
+		hook := hook
+		routed.spanLinkHooks = append(routed.spanLinkHooks, func(ctx context.Context, name string, link SpanHandle) {
+			if match(name) {
+				hook(ctx, name, link)
+			}
+		})
+	}
+
+	return c.Merge(routed)
+}
+
+// PowerFromContext returns the Power in the context.
+func PowerFromContext(ctx context.Context) (Power, bool) {
+	pwr, ok := ctx.Value(powerContextKey).(Power)
+
+	return pwr, ok
+}
+
+// ExtendPower returns a context with the Power of ctx merged with the child Power,
+// e.g. to add the hooks of a tenant or a plugin without losing the hooks of the parent.
+// Example:
+//
+//	ctx = talker.ExtendPower(ctx, talker.NewPower().WithEventHook(tenantHook))
+func ExtendPower(ctx context.Context, child Power) context.Context {
+	parent, _ := PowerFromContext(ctx)
+
+	return context.WithValue(ctx, powerContextKey, parent.Merge(child))
+}
+
+// HookPanicHandler is called when a hook panics, with the span, event or metric name and the recovered value.
+type HookPanicHandler func(ctx context.Context, name string, recovered any)
+
+// WithHookPanicHandler sets the function called when a hook panics.
+// A panicking hook never breaks the caller of Span, Event, Counter, Gauge or Histogram,
+// by default the panic is logged with slog.Default.
+func (c Power) WithHookPanicHandler(handler HookPanicHandler) Power {
+	c.hookPanicHandler = handler
+
+	return c
+}
+
+// recoverHook must be deferred directly, so recover stops the panic.
+func (c Power) recoverHook(ctx context.Context, name string) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+
+	if c.hookPanicHandler != nil {
+		c.hookPanicHandler(ctx, name, recovered)
+		return
+	}
+
+	// Mark the context, so an EventHandler does not send the log back to the hooks.
+	slog.Default().ErrorContext(context.WithValue(ctx, slogContextKey, true), "talker: hook panicked", "name", name, "panic", recovered)
+}
+
+func (c Power) callSpanHook(hook SpanHook, ctx context.Context, name string, params map[string]any) (newCtx context.Context, end func()) {
+	newCtx, end = ctx, noopEnd
+
+	defer c.recoverHook(ctx, name)
+
+	hookCtx, hookEnd := hook(ctx, name, params)
+
+	if hookCtx != nil {
+		newCtx = hookCtx
+	}
+
+	if hookEnd != nil {
+		end = func() {
+			defer c.recoverHook(ctx, name)
+
+			hookEnd()
+		}
+	}
+
+	return newCtx, end
+}
+
+func (c Power) callEventHook(hook EventHook, ctx context.Context, name string, attrs map[string]any) {
+	defer c.recoverHook(ctx, name)
+
+	hook(ctx, name, attrs)
+}
+
+func (c Power) callMetricHook(hook MetricHook, ctx context.Context, kind MetricKind, name string, value float64, attrs map[string]any) {
+	defer c.recoverHook(ctx, name)
+
+	hook(ctx, kind, name, value, attrs)
+}
+
+func (c Power) callSpanParamsHook(hook SpanParamsHook, ctx context.Context, name string, params map[string]any) {
+	defer c.recoverHook(ctx, name)
+
+	hook(ctx, name, params)
+}
+
+func (c Power) callSpanErrorHook(hook SpanErrorHook, ctx context.Context, name string, err error) {
+	defer c.recoverHook(ctx, name)
+
+	hook(ctx, name, err)
+}
+
+func (c Power) callSpanLinkHook(hook SpanLinkHook, ctx context.Context, name string, link SpanHandle) {
+	defer c.recoverHook(ctx, name)
+
+	hook(ctx, name, link)
+}
@@ -0,0 +1,137 @@
+package talker_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Arsfiqball/csverse/talker"
+)
+
+func TestComposePower(t *testing.T) {
+	t.Run("merge", func(t *testing.T) {
+		a, b := &eventRecorder{}, &eventRecorder{}
+
+		pwr := talker.NewPower(talker.WithResource(talker.Resource{ServiceName: "a"})).WithEventHook(a.hook).
+			Merge(talker.NewPower(talker.WithResource(talker.Resource{ServiceName: "b"})).WithEventHook(b.hook))
+
+		ctx := pwr.Context(context.Background(), "test")
+		talker.Event(ctx, "ping", nil)
+
+		if _, ok := a.find("ping"); !ok {
+			t.Fatal("hook of the receiver is not called")
+		}
+
+		if _, ok := b.find("ping"); !ok {
+			t.Fatal("hook of the merged power is not called")
+		}
+
+		if talker.ResourceFromContext(ctx).ServiceName != "a" {
+			t.Fatal("resource of the receiver is not kept")
+		}
+	})
+
+	t.Run("matchers", func(t *testing.T) {
+		if !talker.MatchGlob("http.*")("http.server") || talker.MatchGlob("http.*")("db.query") {
+			t.Fatal("glob does not match")
+		}
+
+		if talker.MatchGlob("[")("[") {
+			t.Fatal("invalid glob matches")
+		}
+
+		if !talker.MatchPrefix("billing.")("billing.charge") || talker.MatchPrefix("billing.")("user.signup") {
+			t.Fatal("prefix does not match")
+		}
+	})
+
+	t.Run("route", func(t *testing.T) {
+		all, billing := &eventRecorder{}, &eventRecorder{}
+		spans := &spanRecorder{}
+
+		pwr := talker.NewPower().
+			WithEventHook(all.hook).
+			Route(talker.MatchPrefix("billing."), talker.NewPower().WithEventHook(billing.hook).WithSpanHook(spans.hook))
+
+		ctx := pwr.Context(context.Background(), "test")
+
+		talker.Event(ctx, "billing.charge", nil)
+		talker.Event(ctx, "user.signup", nil)
+
+		_, end := talker.Span(ctx, "billing.refund", nil)
+		end()
+		_, end = talker.Span(ctx, "user.delete", nil)
+		end()
+
+		if _, ok := all.find("user.signup"); !ok {
+			t.Fatal("hook of the parent is filtered")
+		}
+
+		if _, ok := billing.find("user.signup"); ok {
+			t.Fatal("routed hook is not filtered")
+		}
+
+		if _, ok := billing.find("billing.charge"); !ok {
+			t.Fatal("routed hook is not called")
+		}
+
+		if spans.sorted() != "billing.refund" {
+			t.Fatalf("unexpected spans: %s", spans.sorted())
+		}
+	})
+
+	t.Run("extend", func(t *testing.T) {
+		parent, child := &eventRecorder{}, &eventRecorder{}
+
+		ctx := talker.NewPower().WithEventHook(parent.hook).Context(context.Background(), "test")
+		tenantCtx := talker.ExtendPower(ctx, talker.NewPower().WithEventHook(child.hook))
+
+		talker.Event(tenantCtx, "tenant", nil)
+		talker.Event(ctx, "global", nil)
+
+		if _, ok := parent.find("tenant"); !ok {
+			t.Fatal("parent hook is lost")
+		}
+
+		if _, ok := child.find("global"); ok {
+			t.Fatal("child hook leaks into the parent context")
+		}
+
+		if _, ok := child.find("tenant"); !ok {
+			t.Fatal("child hook is not called")
+		}
+	})
+
+	t.Run("panic isolation", func(t *testing.T) {
+		var panicked []string
+
+		after := &eventRecorder{}
+
+		pwr := talker.NewPower().
+			WithHookPanicHandler(func(ctx context.Context, name string, recovered any) {
+				panicked = append(panicked, name)
+			}).
+			WithEventHook(func(ctx context.Context, name string, attrs map[string]any) { panic("bad hook") }).
+			WithEventHook(after.hook).
+			WithSpanHook(func(ctx context.Context, name string, attrs map[string]any) (context.Context, func()) {
+				return ctx, func() { panic("bad end") }
+			}).
+			WithMetricHook(func(ctx context.Context, kind talker.MetricKind, name string, value float64, attrs map[string]any) {
+				panic("bad metric")
+			})
+
+		ctx := pwr.Context(context.Background(), "test")
+
+		talker.Event(ctx, "ping", nil)
+		talker.Counter(ctx, "count", 1, nil)
+		_, end := talker.Span(ctx, "work", nil)
+		end()
+
+		if _, ok := after.find("ping"); !ok {
+			t.Fatal("hook after the panicking hook is not called")
+		}
+
+		if len(panicked) != 3 {
+			t.Fatalf("unexpected panics: %v", panicked)
+		}
+	})
+}
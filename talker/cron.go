@@ -0,0 +1,257 @@
+package talker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrCronInvalid is the error returned when a cron expression can not be parsed.
+var ErrCronInvalid = NewError("CRON_INVALID", "cron expression is invalid")
+
+// Schedule decides when a scheduled job runs.
+type Schedule interface {
+	Next(t time.Time) time.Time // Next returns the first run time after t, or the zero time when there is none.
+	String() string
+}
+
+type intervalSchedule time.Duration
+
+// Every returns a Schedule that runs at a fixed interval, starting one interval after the scheduler starts.
+// It panics when the interval is not positive.
+func Every(interval time.Duration) Schedule {
+	if interval <= 0 {
+		panic("talker: schedule interval must be positive")
+	}
+
+	return intervalSchedule(interval)
+}
+
+func (s intervalSchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(s))
+}
+
+func (s intervalSchedule) String() string {
+	return "@every " + time.Duration(s).String()
+}
+
+type cronSchedule struct {
+	expr    string
+	minute  uint64
+	hour    uint64
+	dom     uint64
+	month   uint64
+	dow     uint64
+	domStar bool
+	dowStar bool
+}
+
+type cronField struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	cronMinute = cronField{0, 59, nil}
+	cronHour   = cronField{0, 23, nil}
+	cronDom    = cronField{1, 31, nil}
+	cronMonth  = cronField{1, 12, map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	cronDow = cronField{0, 7, map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses a standard cron expression with 5 fields: minute, hour, day of month, month and day of week.
+// Fields support "*", lists "1,2", ranges "1-5", steps "*/15" and names "jan" or "mon".
+// When both day fields are restricted, a day matching either of them is used, like cron does.
+// The descriptors @yearly, @monthly, @weekly, @daily, @hourly and "@every <duration>" are supported too.
+// The times are computed in the location of the time given to Next, check out JobOptions.Location.
+// During a daylight saving fall-back, the times of the repeated hour match twice, once per offset.
+// Example:
+//
+//	schedule, err := talker.ParseCron("*/15 9-17 * * mon-fri") // every 15 minutes during office hours
+func ParseCron(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+
+	if strings.HasPrefix(expr, "@every ") {
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expr, "@every ")))
+		if err != nil || interval <= 0 {
+			return nil, ErrCronInvalid.WithInfo(fmt.Sprintf("cron expression %q has an invalid interval", expr))
+		}
+
+		return Every(interval), nil
+	}
+
+	spec := expr
+	if descriptor, ok := cronDescriptors[strings.ToLower(expr)]; ok {
+		spec = descriptor
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, ErrCronInvalid.WithInfo(fmt.Sprintf("cron expression %q must have 5 fields", expr))
+	}
+
+	s := &cronSchedule{expr: expr, domStar: strings.HasPrefix(fields[2], "*"), dowStar: strings.HasPrefix(fields[4], "*")}
+
+	var err error
+
+	for i, target := range []struct {
+		bits  *uint64
+		field cronField
+	}{
+		{&s.minute, cronMinute},
+		{&s.hour, cronHour},
+		{&s.dom, cronDom},
+		{&s.month, cronMonth},
+		{&s.dow, cronDow},
+	} {
+		if *target.bits, err = parseCronField(fields[i], target.field); err != nil {
+			return nil, ErrCronInvalid.WithInfo(fmt.Sprintf("cron expression %q: %s", expr, err))
+		}
+	}
+
+	if s.dow&(1<<7) != 0 { // 7 is sunday too
+		s.dow |= 1
+	}
+
+	return s, nil
+}
+
+func parseCronField(field string, f cronField) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(field, ",") {
+		rangePart, step := part, 1
+
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+
+			rangePart, step = part[:i], n
+		}
+
+		start, end := f.min, f.max
+
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+
+			var err error
+
+			if start, err = parseCronValue(bounds[0], f); err != nil {
+				return 0, err
+			}
+
+			end = start
+
+			if len(bounds) == 2 {
+				if end, err = parseCronValue(bounds[1], f); err != nil {
+					return 0, err
+				}
+			} else if step > 1 {
+				end = f.max // "5/10" means from 5 to the end, every 10
+			}
+
+			if start > end {
+				return 0, fmt.Errorf("invalid range %q", rangePart)
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+func parseCronValue(s string, f cronField) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+
+	return v, nil
+}
+
+func (s *cronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = nextCronMinute(t)
+	limit := t.AddDate(5, 0, 0) // e.g. "0 0 30 2 *" never runs
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = forwardCron(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc))
+			continue
+		}
+
+		if !s.dayMatches(t) {
+			t = forwardCron(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc))
+			continue
+		}
+
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = forwardCron(t, time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc))
+			continue
+		}
+
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = nextCronMinute(t)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+// nextCronMinute returns the start of the minute after t, stepping on the absolute time,
+// since rebuilding the wall clock with time.Date goes back during a daylight saving fall-back.
+func nextCronMinute(t time.Time) time.Time {
+	return t.Truncate(time.Minute).Add(time.Minute).In(t.Location())
+}
+
+// forwardCron returns next, or the minute after t when next is not after t, e.g. in a daylight saving fall-back.
+func forwardCron(t time.Time, next time.Time) time.Time {
+	if !next.After(t) {
+		return nextCronMinute(t)
+	}
+
+	return next
+}
+
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+
+	return dom || dow
+}
+
+func (s *cronSchedule) String() string {
+	return s.expr
+}
@@ -0,0 +1,100 @@
+package talker_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata" // the DST tests do not depend on the zoneinfo of the system
+
+	"github.com/Arsfiqball/csverse/talker"
+)
+
+func TestParseCron(t *testing.T) {
+	from := time.Date(2024, 1, 1, 10, 30, 15, 0, time.UTC) // monday
+
+	cases := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 1, 10, 31, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 1, 10, 45, 0, 0, time.UTC)},
+		{"0 9-17 * * mon-fri", time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{"0 3 * * *", time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC)},
+		{"0 0 * * sun", time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * fri", time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC)}, // day of month or day of week
+		{"5,35 10 * * *", time.Date(2024, 1, 1, 10, 35, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", from.Add(90 * time.Second)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+
+	for _, c := range cases {
+		schedule, err := talker.ParseCron(c.expr)
+		if err != nil {
+			t.Fatalf("%s: %v", c.expr, err)
+		}
+
+		if next := schedule.Next(from); !next.Equal(c.expected) {
+			t.Fatalf("%s: unexpected next: %s, expected: %s", c.expr, next, c.expected)
+		}
+	}
+
+	t.Run("location", func(t *testing.T) {
+		schedule, err := talker.ParseCron("0 9 * * *")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		wib := time.FixedZone("WIB", 7*60*60)
+		next := schedule.Next(from.In(wib)) // 17:30 in WIB
+
+		if !next.Equal(time.Date(2024, 1, 2, 2, 0, 0, 0, time.UTC)) {
+			t.Fatalf("unexpected next: %s", next)
+		}
+	})
+
+	t.Run("daylight saving fall-back", func(t *testing.T) {
+		chicago, err := time.LoadLocation("America/Chicago")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		schedule, err := talker.ParseCron("* * * * *")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// 01:30 CST on 2024-11-03, the second 01:30 of the day.
+		from := time.Date(2024, 11, 3, 7, 30, 0, 0, time.UTC).In(chicago)
+
+		if next := schedule.Next(from); !next.Equal(from.Add(time.Minute)) {
+			t.Fatalf("unexpected next: %s", next)
+		}
+
+		hourly, err := talker.ParseCron("0 * * * *")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Every run time of the repeated hour moves forward.
+		at := time.Date(2024, 11, 3, 5, 0, 0, 0, time.UTC).In(chicago)
+		for i := 0; i < 4; i++ {
+			next := hourly.Next(at)
+			if !next.Equal(at.Add(time.Hour)) {
+				t.Fatalf("unexpected next after %s: %s", at, next)
+			}
+
+			at = next
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, expr := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "* * * foo *", "@every -1s"} {
+			if _, err := talker.ParseCron(expr); !errors.Is(err, talker.ErrCronInvalid) {
+				t.Fatalf("%q: expression is not rejected", expr)
+			}
+		}
+	})
+}
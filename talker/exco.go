@@ -3,10 +3,12 @@ package talker
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"net"
 	"net/http"
 	"os"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -29,8 +31,15 @@ type Callback func(context.Context) error
 //	)(context.Background())
 func Sequential(callbacks ...Callback) Callback {
 	return func(ctx context.Context) error {
-		for _, callback := range callbacks {
-			if err := callback(ctx); err != nil {
+		ctx, end := combinatorSpan(ctx, "talker.sequential", Params{"steps": len(callbacks)})
+		defer end()
+
+		for i, callback := range callbacks {
+			stepCtx, endStep := combinatorSpan(ctx, "talker.sequential.step", Params{"index": i})
+			err := callback(stepCtx)
+			endStep()
+
+			if err != nil {
 				return err
 			}
 		}
@@ -54,17 +63,24 @@ func Sequential(callbacks ...Callback) Callback {
 //	)(context.Background())
 func Parallel(callbacks ...Callback) Callback {
 	return func(ctx context.Context) error {
+		ctx, end := combinatorSpan(ctx, "talker.parallel", Params{"steps": len(callbacks)})
+		defer end()
+
 		var wg sync.WaitGroup
 
 		errChan := make(chan error, len(callbacks))
 
-		for _, callback := range callbacks {
+		for i, callback := range callbacks {
 			wg.Add(1)
 
-			go func(w *sync.WaitGroup, callback Callback) {
+			go func(w *sync.WaitGroup, i int, callback Callback) {
 				defer w.Done()
-				errChan <- callback(ctx)
-			}(&wg, callback)
+
+				stepCtx, endStep := combinatorSpan(ctx, "talker.parallel.step", Params{"index": i})
+				defer endStep()
+
+				errChan <- callback(stepCtx)
+			}(&wg, i, callback)
 		}
 
 		wg.Wait()
@@ -80,6 +96,131 @@ func Parallel(callbacks ...Callback) Callback {
 	}
 }
 
+// ErrParallelStep wraps the error of a callback run by ParallelWith,
+// the data of the error is a ParallelFailure that tells which callback failed.
+var ErrParallelStep = NewError("PARALLEL_STEP", "parallel step failed")
+
+// ParallelFailure is the data of ErrParallelStep.
+type ParallelFailure struct {
+	Index int    // Index is the position of the callback.
+	Name  string // Name is the name of the callback from ParallelOptions.Names, empty when it is not set.
+}
+
+// ParallelOptions is the configuration for the ParallelWith function.
+type ParallelOptions struct {
+	Limit    int      // Limit is the maximum number of callbacks running at the same time, there is no limit when it is 0.
+	FailFast bool     // FailFast cancels the context of the other callbacks on the first error, and returns only that error.
+	Names    []string // Names are used to tell which callback failed, by position.
+}
+
+// ParallelWith runs all callbacks in parallel with the given options.
+// With a limit, no more than Limit goroutines are started, so it can be used for large fan-outs.
+// Every error is wrapped with ErrParallelStep, by default all callbacks run and the errors are joined (collect-all).
+// With FailFast, the callbacks that are not started yet are skipped after the first error.
+// Example:
+//
+//	err := talker.ParallelWith(
+//		talker.ParallelOptions{Limit: 8, FailFast: true, Names: []string{"users", "orders"}},
+//		func(ctx context.Context) error {
+//			// ... do something
+//			return nil
+//		},
+//		func(ctx context.Context) error {
+//			// ... do something
+//			return nil
+//		},
+//	)(context.Background())
+func ParallelWith(opts ParallelOptions, callbacks ...Callback) Callback {
+	return func(ctx context.Context) error {
+		ctx, end := combinatorSpan(ctx, "talker.parallel", Params{"steps": len(callbacks), "limit": opts.Limit})
+		defer end()
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		var (
+			wg       sync.WaitGroup
+			mu       sync.Mutex
+			firstErr error
+		)
+
+		errs := make([]error, len(callbacks)) // by index, so the joined error has a stable order
+		skipped := false
+
+		limit := opts.Limit
+		if limit <= 0 || limit > len(callbacks) {
+			limit = len(callbacks)
+		}
+
+		sem := make(chan struct{}, limit)
+
+		for i, callback := range callbacks {
+			sem <- struct{}{}
+
+			if opts.FailFast && ctx.Err() != nil {
+				<-sem
+				skipped = true
+				break
+			}
+
+			wg.Add(1)
+
+			go func(i int, callback Callback) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				failure := ParallelFailure{Index: i}
+				if i < len(opts.Names) {
+					failure.Name = opts.Names[i]
+				}
+
+				stepCtx, endStep := combinatorSpan(ctx, "talker.parallel.step", Params{"index": i, "name": failure.Name})
+				err := callback(stepCtx)
+				endStep()
+
+				if err == nil {
+					return
+				}
+
+				label := strconv.Itoa(i)
+				if failure.Name != "" {
+					label = failure.Name
+				}
+
+				err = ErrParallelStep.
+					Wrap(err).
+					WithInfo(fmt.Sprintf("parallel step %s failed: %s", label, err)).
+					WithData(failure)
+
+				mu.Lock()
+				defer mu.Unlock()
+
+				if firstErr == nil {
+					firstErr = err
+				}
+
+				errs[i] = err
+
+				if opts.FailFast {
+					cancel()
+				}
+			}(i, callback)
+		}
+
+		wg.Wait()
+
+		if opts.FailFast {
+			if firstErr == nil && skipped {
+				return ctx.Err() // the parent context is done
+			}
+
+			return firstErr
+		}
+
+		return errors.Join(errs...)
+	}
+}
+
 // Timeout runs callback with timeout.
 // Example:
 //
@@ -92,6 +233,9 @@ func Parallel(callbacks ...Callback) Callback {
 //	)(context.Background())
 func Timeout(callback Callback, timeout time.Duration) Callback {
 	return func(ctx context.Context) error {
+		ctx, end := combinatorSpan(ctx, "talker.timeout", Params{"timeout": timeout.String()})
+		defer end()
+
 		ctx, cancel := context.WithTimeout(ctx, timeout)
 		defer cancel()
 
@@ -99,7 +243,8 @@ func Timeout(callback Callback, timeout time.Duration) Callback {
 	}
 }
 
-// Retry runs callback with retries.
+// Retry runs callback with retries, waiting delay between them.
+// Waiting stops as soon as the context is done, check out RetryWith for backoff and retry conditions.
 // Example:
 //
 //	err := talker.Retry(
@@ -112,21 +257,67 @@ func Timeout(callback Callback, timeout time.Duration) Callback {
 //	)(context.Background())
 func Retry(callback Callback, retries int, delay time.Duration) Callback {
 	return func(ctx context.Context) error {
+		ctx, end := combinatorSpan(ctx, "talker.retry", Params{"retries": retries})
+		defer end()
+
 		var err error
 
 		for i := 0; i < retries; i++ {
-			err = callback(ctx)
+			attemptCtx, endAttempt := combinatorSpan(ctx, "talker.retry.attempt", Params{"attempt": i + 1})
+			err = callback(attemptCtx)
+			endAttempt()
+
 			if err == nil {
 				return nil
 			}
 
-			time.Sleep(delay)
+			if i == retries-1 {
+				break
+			}
+
+			if ctxErr := sleepContext(ctx, delay); ctxErr != nil {
+				return errors.Join(err, ctxErr)
+			}
 		}
 
 		return err
 	}
 }
 
+// Traced runs callback inside a span with the given name and params.
+// When callback fails, the error is recorded with SpanHandle.RecordError, which sends an event
+// named after the span with an ".error" suffix with the error data as the "error" param.
+// Example:
+//
+//	err := talker.Traced("import.users", talker.Params{"file": name},
+//		func(ctx context.Context) error {
+//			// ... do something
+//			return nil
+//		},
+//	)(ctx)
+func Traced(name string, params Params, callback Callback) Callback {
+	return func(ctx context.Context) error {
+		ctx, end := Span(ctx, name, params)
+		defer end()
+
+		err := callback(ctx)
+		SpanFromContext(ctx).RecordError(err)
+
+		return err
+	}
+}
+
+// combinatorSpan starts a span for the Sequential, Parallel, Retry and Timeout combinators,
+// when the Power in the context is created with WithCombinatorSpans.
+func combinatorSpan(ctx context.Context, name string, params Params) (context.Context, func()) {
+	pwr, ok := ctx.Value(powerContextKey).(Power)
+	if !ok || !pwr.combinatorSpans {
+		return ctx, noopEnd
+	}
+
+	return Span(ctx, name, params)
+}
+
 // IgnoreError runs callback and ignore the error.
 // Example:
 //
@@ -144,6 +335,7 @@ func IgnoreError(callback Callback) Callback {
 }
 
 // Atomic runs commit and rollback in sequence.
+// The result of rollback is returned when commit fails, check out Saga to keep the commit error.
 // Example:
 //
 //	err := talker.Atomic(
@@ -170,12 +362,34 @@ func Atomic(commit Callback, rollback Callback) Callback {
 // Process is a process that can be run.
 // This struct is used by the Serve function (check out the example in the Serve function).
 type Process struct {
-	Start       Callback     // Start is a callback that runs when the process starts.
-	Live        Callback     // Live is a callback that runs periodically to check if the process is still alive.
-	Ready       Callback     // Ready is a callback that runs periodically to check if the process is ready to serve requests.
-	Stop        Callback     // Stop is a callback that runs when the process stops.
-	Logger      *slog.Logger // Logger is the logger used by the process.
-	MonitorAddr string       // MonitorAddr is the address used by the process to serve health check requests.
+	Start       Callback         // Start is a callback that runs when the process starts.
+	Live        Callback         // Live is a callback that runs periodically to check if the process is still alive.
+	Ready       Callback         // Ready is a callback that runs periodically to check if the process is ready to serve requests.
+	Stop        Callback         // Stop is a callback that runs when the process stops.
+	Logger      *slog.Logger     // Logger is the logger used by the process.
+	MonitorAddr string           // MonitorAddr is the address used by the process to serve health check requests.
+	Metrics     *MetricStore     // Metrics is served by the monitor server at /metrics when it is set.
+	Tracer      *TraceRecorder   // Tracer is served by the monitor server at /debug/trace when it is set.
+	Profiling   bool             // Profiling serves the runtime/pprof profiles at /debug/pprof/ on the monitor server only.
+	Breakers    *BreakerRegistry // Breakers is served by the monitor server at /breakers when it is set.
+	Scheduler   *Scheduler       // Scheduler is served by the monitor server at /scheduler when it is set.
+}
+
+// sleepContext waits for the duration, or returns the context error as soon as the context is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 func emptyCallback(ctx context.Context) error {
@@ -224,6 +438,35 @@ func callbackToHealthCheckHandler(cb Callback) http.HandlerFunc {
 	}
 }
 
+func monitorMux(proc Process) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/live", callbackToHealthCheckHandler(proc.Live))
+	mux.HandleFunc("/ready", callbackToHealthCheckHandler(proc.Ready))
+
+	if proc.Metrics != nil {
+		mux.Handle("/metrics", proc.Metrics)
+	}
+
+	if proc.Tracer != nil {
+		mux.Handle("/debug/trace", proc.Tracer)
+	}
+
+	if proc.Profiling {
+		mux.HandleFunc("/debug/pprof/", profileHandler)
+	}
+
+	if proc.Breakers != nil {
+		mux.Handle("/breakers", proc.Breakers)
+	}
+
+	if proc.Scheduler != nil {
+		mux.Handle("/scheduler", proc.Scheduler)
+	}
+
+	return mux
+}
+
 // Serve runs the process.
 // Example:
 //
@@ -246,6 +489,11 @@ func callbackToHealthCheckHandler(cb Callback) http.HandlerFunc {
 //		},
 //		Logger: slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{})),
 //		MonitorAddr: ":8086", // Monitor address, default is ":0" (random port)
+//		Metrics: talker.NewMetricStore(), // Optional, served at /metrics
+//		Tracer: talker.NewTraceRecorder(0), // Optional, served at /debug/trace?seconds=5
+//		Profiling: true, // Optional, serves /debug/pprof/profile?seconds=30, /debug/pprof/heap, etc.
+//		Breakers: talker.DefaultBreakerRegistry, // Optional, served at /breakers
+//		Scheduler: scheduler, // Optional, served at /scheduler (check out NewScheduler)
 //	}
 //
 //	sig := make(chan os.Signal, 1)
@@ -260,14 +508,9 @@ func Serve(proc Process, stopSignal chan os.Signal) {
 
 	// Health check server
 	go func() {
-		mux := http.NewServeMux()
-
-		mux.HandleFunc("/live", callbackToHealthCheckHandler(proc.Live))
-		mux.HandleFunc("/ready", callbackToHealthCheckHandler(proc.Ready))
-
 		server := http.Server{
 			Addr:    proc.MonitorAddr,
-			Handler: mux,
+			Handler: monitorMux(proc),
 		}
 
 		listener, err := net.Listen("tcp", server.Addr)
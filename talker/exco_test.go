@@ -0,0 +1,224 @@
+package talker_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Arsfiqball/csverse/talker"
+)
+
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []string
+}
+
+func (r *spanRecorder) hook(ctx context.Context, name string, attrs map[string]any) (context.Context, func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.spans = append(r.spans, name)
+
+	return ctx, func() {}
+}
+
+func (r *spanRecorder) sorted() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	spans := append([]string{}, r.spans...)
+	sort.Strings(spans)
+
+	return strings.Join(spans, ",")
+}
+
+func TestTraced(t *testing.T) {
+	spans := &spanRecorder{}
+	events := &eventRecorder{}
+
+	ctx := talker.NewPower().
+		WithSpanHook(spans.hook).
+		WithEventHook(events.hook).
+		Context(context.Background(), "test")
+
+	errFailed := talker.NewError("FAILED", "failed")
+
+	err := talker.Traced("import", talker.Params{"file": "users.csv"}, func(ctx context.Context) error {
+		return errFailed
+	})(ctx)
+
+	if !errors.Is(err, errFailed) {
+		t.Fatal("error is not returned")
+	}
+
+	if spans.sorted() != "import" {
+		t.Fatalf("unexpected spans: %s", spans.sorted())
+	}
+
+	e, ok := events.find("import.error")
+	if !ok || e.attrs["error"].([]talker.ErrorData)[0].Code != "FAILED" {
+		t.Fatal("error event is not sent")
+	}
+}
+
+func TestCombinatorSpans(t *testing.T) {
+	noop := func(ctx context.Context) error { return nil }
+
+	t.Run("disabled", func(t *testing.T) {
+		spans := &spanRecorder{}
+		ctx := talker.NewPower().WithSpanHook(spans.hook).Context(context.Background(), "test")
+
+		if err := talker.Sequential(noop, noop)(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		if spans.sorted() != "" {
+			t.Fatalf("unexpected spans: %s", spans.sorted())
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		spans := &spanRecorder{}
+		ctx := talker.NewPower().WithSpanHook(spans.hook).WithCombinatorSpans().Context(context.Background(), "test")
+
+		err := talker.Sequential(
+			talker.Parallel(noop, noop),
+			talker.Timeout(noop, time.Second),
+			talker.Retry(noop, 3, 0),
+		)(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		expected := strings.Join([]string{
+			"talker.parallel",
+			"talker.parallel.step",
+			"talker.parallel.step",
+			"talker.retry",
+			"talker.retry.attempt",
+			"talker.sequential",
+			"talker.sequential.step",
+			"talker.sequential.step",
+			"talker.sequential.step",
+			"talker.timeout",
+		}, ",")
+
+		if spans.sorted() != expected {
+			t.Fatalf("unexpected spans: %s", spans.sorted())
+		}
+	})
+}
+
+func TestParallelWith(t *testing.T) {
+	errFailed := talker.NewError("FAILED", "failed")
+
+	t.Run("limit", func(t *testing.T) {
+		var (
+			mu      sync.Mutex
+			running int
+			peak    int
+		)
+
+		callbacks := make([]talker.Callback, 100)
+
+		for i := range callbacks {
+			callbacks[i] = func(ctx context.Context) error {
+				mu.Lock()
+				running++
+				if running > peak {
+					peak = running
+				}
+				mu.Unlock()
+
+				time.Sleep(time.Millisecond)
+
+				mu.Lock()
+				running--
+				mu.Unlock()
+
+				return nil
+			}
+		}
+
+		if err := talker.ParallelWith(talker.ParallelOptions{Limit: 4}, callbacks...)(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		if peak > 4 || peak == 0 {
+			t.Fatalf("unexpected peak: %d", peak)
+		}
+	})
+
+	t.Run("collect all", func(t *testing.T) {
+		ran := make([]bool, 3)
+
+		err := talker.ParallelWith(
+			talker.ParallelOptions{Names: []string{"users", "orders"}},
+			func(ctx context.Context) error { ran[0] = true; return errFailed },
+			func(ctx context.Context) error { ran[1] = true; return nil },
+			func(ctx context.Context) error { ran[2] = true; return errors.New("unnamed") },
+		)(context.Background())
+
+		if !ran[0] || !ran[1] || !ran[2] {
+			t.Fatal("not every callback ran")
+		}
+
+		if !errors.Is(err, talker.ErrParallelStep) || !errors.Is(err, errFailed) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err.Error() != "parallel step users failed: failed\nparallel step 2 failed: unnamed" {
+			t.Fatalf("unexpected error message: %q", err.Error())
+		}
+
+		var stepErr talker.Error
+		if !errors.As(err, &stepErr) || stepErr.Data() != (talker.ParallelFailure{Index: 0, Name: "users"}) {
+			t.Fatal("failure is not attributed")
+		}
+	})
+
+	t.Run("fail fast", func(t *testing.T) {
+		var (
+			mu      sync.Mutex
+			started int
+		)
+
+		callbacks := []talker.Callback{
+			func(ctx context.Context) error {
+				return errFailed
+			},
+			func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		}
+
+		for i := 0; i < 10; i++ {
+			callbacks = append(callbacks, func(ctx context.Context) error {
+				mu.Lock()
+				started++
+				mu.Unlock()
+				return nil
+			})
+		}
+
+		err := talker.ParallelWith(talker.ParallelOptions{Limit: 2, FailFast: true}, callbacks...)(context.Background())
+
+		if !errors.Is(err, errFailed) || errors.Is(err, context.Canceled) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var stepErr talker.Error
+		if !errors.As(err, &stepErr) || stepErr.Data().(talker.ParallelFailure).Index != 0 {
+			t.Fatal("failure is not attributed")
+		}
+
+		if started == 10 {
+			t.Fatal("callbacks are not skipped")
+		}
+	})
+}
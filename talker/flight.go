@@ -0,0 +1,207 @@
+package talker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrFlightPanic is the error shared with every caller when a coalesced call panics,
+// the data of the error is the recovered value.
+var ErrFlightPanic = NewError("FLIGHT_PANIC", "coalesced call panicked")
+
+// ErrThrottled is the error returned when a call is rejected by Throttle,
+// the data of the error is the time.Duration until the next call is allowed.
+var ErrThrottled = NewError("THROTTLED", "call is throttled")
+
+// FlightGroup coalesces concurrent calls with the same key into one execution,
+// the value and the error are shared with every caller.
+// The execution is cancelled only when every caller gave up, i.e. their contexts are done.
+// This struct must be created with the NewFlightGroup function.
+type FlightGroup[T any] struct {
+	mu    sync.Mutex
+	calls map[string]*flightCall[T]
+}
+
+type flightCall[T any] struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+	value   T
+	err     error
+}
+
+// NewFlightGroup creates a new FlightGroup.
+// Example:
+//
+//	users := talker.NewFlightGroup[User]()
+//
+//	user, err := users.Do(ctx, strconv.Itoa(id), func(ctx context.Context) (User, error) {
+//		return fetchUser(ctx, id) // only one fetch per id at a time
+//	})
+func NewFlightGroup[T any]() *FlightGroup[T] {
+	return &FlightGroup[T]{calls: map[string]*flightCall[T]{}}
+}
+
+// Do runs task, or waits for the running task with the same key and returns its result.
+// The task runs with the context of the first caller, without its cancellation.
+func (g *FlightGroup[T]) Do(ctx context.Context, key string, task Task[T]) (T, error) {
+	g.mu.Lock()
+
+	call, ok := g.calls[key]
+	if !ok {
+		var callCtx context.Context
+
+		call = &flightCall[T]{done: make(chan struct{})}
+		callCtx, call.cancel = context.WithCancel(context.WithoutCancel(ctx))
+		g.calls[key] = call
+
+		go g.run(callCtx, key, call, task)
+	}
+
+	call.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		call.waiters--
+
+		if call.waiters == 0 {
+			call.cancel()
+
+			if g.calls[key] == call { // the next caller starts a new call
+				delete(g.calls, key)
+			}
+		}
+		g.mu.Unlock()
+
+		var zero T
+
+		return zero, ctx.Err()
+	}
+}
+
+func (g *FlightGroup[T]) run(ctx context.Context, key string, call *flightCall[T], task Task[T]) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			call.err = ErrFlightPanic.WithInfo(fmt.Sprintf("coalesced call %s panicked: %v", key, recovered)).WithData(recovered)
+		}
+
+		g.mu.Lock()
+		if g.calls[key] == call {
+			delete(g.calls, key)
+		}
+		g.mu.Unlock()
+
+		call.cancel()
+		close(call.done)
+	}()
+
+	ctx, end := combinatorSpan(ctx, "talker.singleflight", Params{"key": key})
+	defer end()
+
+	call.value, call.err = task(ctx)
+}
+
+// Singleflight coalesces concurrent runs of callback with the same key, check out FlightGroup.
+// Every run has the same key when key is nil.
+// Example:
+//
+//	refresh := talker.Singleflight(refreshCache, func(ctx context.Context) string {
+//		return tenantFromContext(ctx)
+//	})
+func Singleflight(callback Callback, key func(ctx context.Context) string) Callback {
+	group := NewFlightGroup[struct{}]()
+	task := FromCallback(callback)
+
+	return func(ctx context.Context) error {
+		k := ""
+		if key != nil {
+			k = key(ctx)
+		}
+
+		_, err := group.Do(ctx, k, task)
+
+		return err
+	}
+}
+
+// Debounce returns a Callback that triggers callback once the triggers stopped for the wait duration.
+// The returned Callback does not wait for callback and always returns nil.
+// callback runs with the context of the last trigger, without its cancellation, and runs are never concurrent.
+// The errors of callback are sent as "talker.debounce.error" events with the error data as the "error" param.
+// Example:
+//
+//	reindex := talker.Debounce(rebuildSearchIndex, 5*time.Second)
+//
+//	// in the webhook handler, a burst of updates runs rebuildSearchIndex once
+//	_ = reindex(ctx)
+func Debounce(callback Callback, wait time.Duration) Callback {
+	var (
+		mu      sync.Mutex
+		running sync.Mutex
+		timer   *time.Timer
+		lastCtx context.Context
+	)
+
+	fire := func() {
+		mu.Lock()
+		ctx := lastCtx
+		mu.Unlock()
+
+		running.Lock()
+		defer running.Unlock()
+
+		if err := callback(ctx); err != nil {
+			Event(ctx, "talker.debounce.error", Params{"error": ErrorDataFrom(err, 10)})
+		}
+	}
+
+	return func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		lastCtx = context.WithoutCancel(ctx)
+
+		if timer == nil {
+			timer = time.AfterFunc(wait, fire)
+		} else {
+			timer.Reset(wait)
+		}
+
+		return nil
+	}
+}
+
+// Throttle runs callback at most once per interval, the calls in between return ErrThrottled.
+// Example:
+//
+//	notify := talker.Throttle(sendAlert, time.Minute)
+//
+//	err := notify(ctx) // errors.Is(err, talker.ErrThrottled) when an alert was sent less than a minute ago
+func Throttle(callback Callback, interval time.Duration) Callback {
+	var (
+		mu   sync.Mutex
+		last time.Time
+	)
+
+	return func(ctx context.Context) error {
+		mu.Lock()
+
+		now := time.Now()
+		if !last.IsZero() && now.Sub(last) < interval {
+			mu.Unlock()
+
+			return ErrThrottled.WithData(interval - now.Sub(last))
+		}
+
+		last = now
+		mu.Unlock()
+
+		return callback(ctx)
+	}
+}
@@ -0,0 +1,273 @@
+package talker_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Arsfiqball/csverse/talker"
+)
+
+func TestFlightGroup(t *testing.T) {
+	t.Run("shared result", func(t *testing.T) {
+		group := talker.NewFlightGroup[int]()
+		release := make(chan struct{})
+
+		var (
+			calls atomic.Int32
+			wg    sync.WaitGroup
+		)
+
+		task := func(ctx context.Context) (int, error) {
+			calls.Add(1)
+			<-release
+			return 42, nil
+		}
+
+		results := make([]int, 5)
+
+		for i := range results {
+			wg.Add(1)
+
+			go func(i int) {
+				defer wg.Done()
+
+				value, err := group.Do(context.Background(), "answer", task)
+				if err != nil {
+					t.Error(err)
+				}
+
+				results[i] = value
+			}(i)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		if calls.Load() != 1 {
+			t.Fatalf("unexpected calls: %d", calls.Load())
+		}
+
+		for _, value := range results {
+			if value != 42 {
+				t.Fatalf("unexpected results: %v", results)
+			}
+		}
+
+		// The key is released after the call.
+		if _, err := group.Do(context.Background(), "answer", task); err != nil || calls.Load() != 2 {
+			t.Fatal("call is not run again")
+		}
+	})
+
+	t.Run("shared error", func(t *testing.T) {
+		group := talker.NewFlightGroup[int]()
+		errFailed := errors.New("failed")
+		release := make(chan struct{})
+		errs := make(chan error, 2)
+
+		for i := 0; i < 2; i++ {
+			go func() {
+				_, err := group.Do(context.Background(), "key", func(ctx context.Context) (int, error) {
+					<-release
+					return 0, errFailed
+				})
+				errs <- err
+			}()
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+
+		for i := 0; i < 2; i++ {
+			if err := <-errs; !errors.Is(err, errFailed) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	})
+
+	t.Run("cancelled when every caller gave up", func(t *testing.T) {
+		group := talker.NewFlightGroup[int]()
+		cancelled := make(chan struct{})
+
+		ctx1, cancel1 := context.WithCancel(context.Background())
+		ctx2, cancel2 := context.WithCancel(context.Background())
+
+		task := func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			close(cancelled)
+			return 0, ctx.Err()
+		}
+
+		errs := make(chan error, 2)
+
+		go func() { _, err := group.Do(ctx1, "key", task); errs <- err }()
+		go func() { _, err := group.Do(ctx2, "key", task); errs <- err }()
+
+		time.Sleep(10 * time.Millisecond)
+		cancel1()
+
+		if err := <-errs; !errors.Is(err, context.Canceled) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		select {
+		case <-cancelled:
+			t.Fatal("call is cancelled while a caller is waiting")
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		cancel2()
+		<-errs
+		<-cancelled
+	})
+
+	t.Run("new call after every caller gave up", func(t *testing.T) {
+		group := talker.NewFlightGroup[int]()
+		release := make(chan struct{})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errs := make(chan error, 1)
+
+		go func() {
+			_, err := group.Do(ctx, "key", func(ctx context.Context) (int, error) {
+				<-release // ignores the cancellation
+				return 0, ctx.Err()
+			})
+			errs <- err
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+		<-errs
+
+		value, err := group.Do(context.Background(), "key", func(ctx context.Context) (int, error) {
+			return 42, nil
+		})
+		if err != nil || value != 42 {
+			t.Fatalf("cancelled call is joined: %d, %v", value, err)
+		}
+
+		close(release)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		group := talker.NewFlightGroup[int]()
+
+		_, err := group.Do(context.Background(), "key", func(ctx context.Context) (int, error) {
+			panic("boom")
+		})
+
+		var flightErr talker.Error
+		if !errors.Is(err, talker.ErrFlightPanic) || !errors.As(err, &flightErr) || flightErr.Data() != "boom" {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestSingleflight(t *testing.T) {
+	var calls atomic.Int32
+
+	release := make(chan struct{})
+
+	refresh := talker.Singleflight(func(ctx context.Context) error {
+		calls.Add(1)
+		<-release
+		return nil
+	}, func(ctx context.Context) string {
+		return ctx.Value(tenantKey{}).(string)
+	})
+
+	var wg sync.WaitGroup
+
+	for _, tenant := range []string{"a", "a", "b", "a", "b"} {
+		wg.Add(1)
+
+		go func(tenant string) {
+			defer wg.Done()
+			_ = refresh(context.WithValue(context.Background(), tenantKey{}, tenant))
+		}(tenant)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 2 {
+		t.Fatalf("unexpected calls: %d", calls.Load())
+	}
+}
+
+type tenantKey struct{}
+
+func TestDebounce(t *testing.T) {
+	var calls atomic.Int32
+
+	events := &eventRecorder{}
+	ctx := talker.NewPower().WithEventHook(events.hook).Context(context.Background(), "test")
+
+	trigger := talker.Debounce(func(ctx context.Context) error {
+		calls.Add(1)
+		return errors.New("failed")
+	}, 20*time.Millisecond)
+
+	for i := 0; i < 5; i++ {
+		if err := trigger(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if calls.Load() != 0 {
+		t.Fatal("callback runs during the burst")
+	}
+
+	waitFor(t, func() bool { return calls.Load() == 1 })
+
+	time.Sleep(30 * time.Millisecond)
+
+	if calls.Load() != 1 {
+		t.Fatalf("unexpected calls: %d", calls.Load())
+	}
+
+	if _, ok := events.find("talker.debounce.error"); !ok {
+		t.Fatal("error event is not sent")
+	}
+
+	_ = trigger(ctx)
+	waitFor(t, func() bool { return calls.Load() == 2 })
+}
+
+func TestThrottle(t *testing.T) {
+	calls := 0
+
+	cb := talker.Throttle(func(ctx context.Context) error {
+		calls++
+		return nil
+	}, 20*time.Millisecond)
+
+	if err := cb(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	err := cb(context.Background())
+	if !errors.Is(err, talker.ErrThrottled) {
+		t.Fatalf("call is not throttled: %v", err)
+	}
+
+	var throttleErr talker.Error
+	if !errors.As(err, &throttleErr) || throttleErr.Data().(time.Duration) <= 0 {
+		t.Fatal("remaining time is not set as data")
+	}
+
+	time.Sleep(25 * time.Millisecond)
+
+	if err := cb(context.Background()); err != nil || calls != 2 {
+		t.Fatalf("call is not run after the interval: %v", err)
+	}
+}
@@ -0,0 +1,340 @@
+package talker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrGraphInvalid is the error returned when a graph has a duplicate step, an unknown dependency or a cycle.
+var ErrGraphInvalid = NewError("GRAPH_INVALID", "graph is invalid")
+
+// ErrGraphFailed is the error returned when a step of a graph fails, the data of the error is the GraphReport.
+var ErrGraphFailed = NewError("GRAPH_FAILED", "graph failed")
+
+// ErrGraphStep wraps the error of a failed step of a graph.
+var ErrGraphStep = NewError("GRAPH_STEP", "graph step failed")
+
+// StepStatus is the status of a step of a graph.
+type StepStatus int
+
+const (
+	StepPending   StepStatus = iota // StepPending is the status of a step that did not run.
+	StepSucceeded                   // StepSucceeded is the status of a step that returned no error.
+	StepFailed                      // StepFailed is the status of a step that returned an error.
+	StepSkipped                     // StepSkipped is the status of a step whose dependency did not succeed, or whose context was done.
+)
+
+// String returns the name of the status.
+func (s StepStatus) String() string {
+	switch s {
+	case StepSucceeded:
+		return "succeeded"
+	case StepFailed:
+		return "failed"
+	case StepSkipped:
+		return "skipped"
+	default:
+		return "pending"
+	}
+}
+
+// MarshalJSON writes the status as its name.
+func (s StepStatus) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// StepReport is the result of a step of a graph.
+type StepReport struct {
+	Name     string
+	Status   StepStatus
+	Start    time.Time     // Start is zero when the step did not run.
+	Duration time.Duration // Duration is zero when the step did not run.
+	Err      error         // Err is the error returned by the step.
+}
+
+// GraphReport is the result of a graph run, the steps are in the order they were added.
+type GraphReport struct {
+	Start    time.Time
+	Duration time.Duration
+	Steps    []StepReport
+}
+
+// Step returns the report of the step with the given name.
+func (r GraphReport) Step(name string) (StepReport, bool) {
+	for _, step := range r.Steps {
+		if step.Name == name {
+			return step, true
+		}
+	}
+
+	return StepReport{}, false
+}
+
+type graphStep struct {
+	name     string
+	callback Callback
+	deps     []string
+}
+
+// Graph runs named steps that depend on each other.
+// A step runs once all its dependencies succeeded, independent steps run concurrently.
+// When a step fails, the steps that depend on it, directly or not, are skipped, the other steps keep running.
+// This struct must be created with the NewGraph function.
+type Graph struct {
+	limit int
+	steps []graphStep
+}
+
+// NewGraph creates a new Graph that runs up to limit steps at the same time, there is no limit when it is 0.
+// Example:
+//
+//	deploy := talker.NewGraph(4).
+//		Step("build", build).
+//		Step("migrate", migrate).
+//		Step("deploy", deploy, "build", "migrate").
+//		Step("warm_cache", warmCache, "build")
+//
+//	report, err := deploy.Run(ctx)
+//	for _, step := range report.Steps {
+//		fmt.Println(step.Name, step.Status, step.Duration, step.Err)
+//	}
+func NewGraph(limit int) *Graph {
+	return &Graph{limit: limit}
+}
+
+// Step adds a step that runs after the given dependencies, it returns the graph so calls can be chained.
+// The dependencies can be added later, they are checked by Validate.
+func (g *Graph) Step(name string, callback Callback, deps ...string) *Graph {
+	g.steps = append(g.steps, graphStep{name: name, callback: callback, deps: deps})
+	return g
+}
+
+// Validate checks that step names are unique, dependencies exist and there is no cycle.
+func (g *Graph) Validate() error {
+	index := make(map[string]int, len(g.steps))
+
+	for i, step := range g.steps {
+		if _, ok := index[step.name]; ok {
+			return ErrGraphInvalid.WithInfo(fmt.Sprintf("graph step %s is added twice", step.name))
+		}
+
+		index[step.name] = i
+	}
+
+	for _, step := range g.steps {
+		for _, dep := range step.deps {
+			if _, ok := index[dep]; !ok {
+				return ErrGraphInvalid.WithInfo(fmt.Sprintf("graph step %s depends on unknown step %s", step.name, dep))
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+
+	state := make([]int, len(g.steps))
+	path := []string{}
+
+	var visit func(i int) error
+
+	visit = func(i int) error {
+		switch state[i] {
+		case visited:
+			return nil
+		case visiting:
+			for j, name := range path {
+				if name == g.steps[i].name {
+					path = append(path[j:], name)
+					break
+				}
+			}
+
+			return ErrGraphInvalid.WithInfo("graph has a cycle: " + strings.Join(path, " -> "))
+		}
+
+		state[i] = visiting
+		path = append(path, g.steps[i].name)
+
+		for _, dep := range g.steps[i].deps {
+			if err := visit(index[dep]); err != nil {
+				return err
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[i] = visited
+
+		return nil
+	}
+
+	for i := range g.steps {
+		if err := visit(i); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Callback returns a Callback that runs the graph and drops the report.
+func (g *Graph) Callback() Callback {
+	return func(ctx context.Context) error {
+		_, err := g.Run(ctx)
+		return err
+	}
+}
+
+// Run validates and runs the graph. When a step fails, ErrGraphFailed is returned,
+// it wraps the errors of the failed steps (ErrGraphStep), in the order the steps were added.
+// The steps that did not start yet are skipped when the context is done.
+func (g *Graph) Run(ctx context.Context) (GraphReport, error) {
+	report := GraphReport{Start: time.Now(), Steps: make([]StepReport, len(g.steps))}
+
+	for i, step := range g.steps {
+		report.Steps[i] = StepReport{Name: step.name}
+	}
+
+	if err := g.Validate(); err != nil {
+		return report, err
+	}
+
+	ctx, end := combinatorSpan(ctx, "talker.graph", Params{"steps": len(g.steps), "limit": g.limit})
+	defer end()
+
+	index := make(map[string]int, len(g.steps))
+	for i, step := range g.steps {
+		index[step.name] = i
+	}
+
+	dependents := make([][]int, len(g.steps))
+	remaining := make([]int, len(g.steps))
+	blocked := make([]bool, len(g.steps)) // a dependency did not succeed
+	ready := []int{}
+
+	for i, step := range g.steps {
+		remaining[i] = len(step.deps)
+
+		for _, dep := range step.deps {
+			dependents[index[dep]] = append(dependents[index[dep]], i)
+		}
+
+		if remaining[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	type result struct {
+		i        int
+		start    time.Time
+		duration time.Duration
+		err      error
+	}
+
+	results := make(chan result)
+	done, running := 0, 0
+
+	var resolve func(i int, ok bool)
+
+	resolve = func(i int, ok bool) {
+		for _, d := range dependents[i] {
+			blocked[d] = blocked[d] || !ok
+			remaining[d]--
+
+			if remaining[d] > 0 {
+				continue
+			}
+
+			if blocked[d] {
+				report.Steps[d].Status = StepSkipped
+				done++
+				resolve(d, false)
+
+				continue
+			}
+
+			ready = append(ready, d)
+		}
+	}
+
+	for done < len(g.steps) {
+		for len(ready) > 0 && (g.limit <= 0 || running < g.limit) {
+			i := ready[0]
+			ready = ready[1:]
+
+			if ctx.Err() != nil {
+				report.Steps[i].Status = StepSkipped
+				done++
+				resolve(i, false)
+
+				continue
+			}
+
+			running++
+
+			go func(i int) {
+				start := time.Now()
+
+				stepCtx, endStep := combinatorSpan(ctx, "talker.graph.step", Params{"name": g.steps[i].name})
+				err := g.steps[i].callback(stepCtx)
+				endStep()
+
+				// The duration is measured here, the result may wait for the loop to receive it.
+				results <- result{i: i, start: start, duration: time.Since(start), err: err}
+			}(i)
+		}
+
+		if running == 0 {
+			break // nothing left to wait for, it does not happen with a validated graph
+		}
+
+		r := <-results
+		running--
+		done++
+
+		step := &report.Steps[r.i]
+		step.Start = r.start
+		step.Duration = r.duration
+		step.Status = StepSucceeded
+
+		if r.err != nil {
+			step.Status = StepFailed
+			step.Err = ErrGraphStep.Wrap(r.err).WithInfo(fmt.Sprintf("graph step %s failed: %s", step.Name, r.err)).WithData(step.Name)
+		}
+
+		resolve(r.i, r.err == nil)
+	}
+
+	report.Duration = time.Since(report.Start)
+
+	errs := []error{}
+	failed := []string{}
+
+	for _, step := range report.Steps {
+		if step.Status == StepFailed {
+			errs = append(errs, step.Err)
+			failed = append(failed, step.Name)
+		}
+	}
+
+	if len(errs) > 0 {
+		return report, ErrGraphFailed.
+			Wrap(errors.Join(errs...)).
+			WithInfo("graph steps failed: " + strings.Join(failed, ", ")).
+			WithData(report)
+	}
+
+	for _, step := range report.Steps {
+		if step.Status == StepSkipped {
+			return report, ctx.Err() // without a failure, steps are only skipped when the context is done
+		}
+	}
+
+	return report, nil
+}
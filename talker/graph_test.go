@@ -0,0 +1,173 @@
+package talker_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Arsfiqball/csverse/talker"
+)
+
+func TestGraph(t *testing.T) {
+	errFailed := talker.NewError("FAILED", "failed")
+
+	t.Run("validate", func(t *testing.T) {
+		noop := func(ctx context.Context) error { return nil }
+
+		cases := map[string]*talker.Graph{
+			"graph step a is added twice":            talker.NewGraph(0).Step("a", noop).Step("a", noop),
+			"graph step a depends on unknown step b": talker.NewGraph(0).Step("a", noop, "b"),
+			"graph has a cycle: b -> c -> b":         talker.NewGraph(0).Step("a", noop, "b").Step("b", noop, "c").Step("c", noop, "b"),
+		}
+
+		for expected, g := range cases {
+			err := g.Validate()
+			if !errors.Is(err, talker.ErrGraphInvalid) || err.Error() != expected {
+				t.Fatalf("unexpected error: %v, expected: %s", err, expected)
+			}
+
+			if _, err := g.Run(context.Background()); !errors.Is(err, talker.ErrGraphInvalid) {
+				t.Fatal("invalid graph is run")
+			}
+		}
+	})
+
+	t.Run("dependencies", func(t *testing.T) {
+		var mu sync.Mutex
+
+		log := []string{}
+		step := func(name string) talker.Callback {
+			return func(ctx context.Context) error {
+				time.Sleep(5 * time.Millisecond)
+
+				mu.Lock()
+				log = append(log, name)
+				mu.Unlock()
+
+				return nil
+			}
+		}
+
+		report, err := talker.NewGraph(0).
+			Step("c", step("c"), "a", "b").
+			Step("d", step("d"), "a").
+			Step("a", step("a")).
+			Step("b", step("b"), "a").
+			Run(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		order := strings.Join(log, ",")
+		if order != "a,b,d,c" && order != "a,d,b,c" {
+			t.Fatalf("unexpected order: %s", order)
+		}
+
+		for _, step := range report.Steps {
+			if step.Status != talker.StepSucceeded || step.Duration <= 0 || step.Start.IsZero() {
+				t.Fatalf("unexpected report: %+v", step)
+			}
+		}
+
+		if report.Steps[0].Name != "c" {
+			t.Fatal("report is not in the order steps were added")
+		}
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		var (
+			mu      sync.Mutex
+			running int
+			peak    int
+		)
+
+		step := func(ctx context.Context) error {
+			mu.Lock()
+			running++
+			if running > peak {
+				peak = running
+			}
+			mu.Unlock()
+
+			time.Sleep(5 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+
+			return nil
+		}
+
+		g := talker.NewGraph(2)
+		for _, name := range []string{"a", "b", "c", "d", "e"} {
+			g.Step(name, step)
+		}
+
+		if _, err := g.Run(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		if peak != 2 {
+			t.Fatalf("unexpected peak: %d", peak)
+		}
+	})
+
+	t.Run("failure skips downstream", func(t *testing.T) {
+		noop := func(ctx context.Context) error { return nil }
+
+		report, err := talker.NewGraph(0).
+			Step("extract", noop).
+			Step("transform", func(ctx context.Context) error { return errFailed }, "extract").
+			Step("load", noop, "transform").
+			Step("notify", noop, "load").
+			Step("audit", noop, "extract").
+			Run(context.Background())
+
+		if !errors.Is(err, talker.ErrGraphFailed) || !errors.Is(err, talker.ErrGraphStep) || !errors.Is(err, errFailed) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err.Error() != "graph steps failed: transform" {
+			t.Fatalf("unexpected error message: %s", err.Error())
+		}
+
+		expected := map[string]talker.StepStatus{
+			"extract":   talker.StepSucceeded,
+			"transform": talker.StepFailed,
+			"load":      talker.StepSkipped,
+			"notify":    talker.StepSkipped,
+			"audit":     talker.StepSucceeded,
+		}
+
+		for name, status := range expected {
+			step, ok := report.Step(name)
+			if !ok || step.Status != status {
+				t.Fatalf("unexpected status of %s: %s", name, step.Status)
+			}
+		}
+
+		if step, _ := report.Step("transform"); !errors.Is(step.Err, errFailed) {
+			t.Fatal("step error is not reported")
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		report, err := talker.NewGraph(0).
+			Step("a", func(ctx context.Context) error { cancel(); return nil }).
+			Step("b", func(ctx context.Context) error { return nil }, "a").
+			Run(ctx)
+
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if step, _ := report.Step("b"); step.Status != talker.StepSkipped {
+			t.Fatalf("unexpected status: %s", step.Status)
+		}
+	})
+}
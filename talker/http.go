@@ -0,0 +1,191 @@
+package talker
+
+import (
+	"net/http"
+	"time"
+)
+
+// ErrHTTPPanic is the error used to recover a panic in a handler wrapped by HTTPMiddleware.
+var ErrHTTPPanic = NewError("HTTP_PANIC", "panic while serving HTTP request")
+
+// HTTPMiddleware returns a middleware that adds the Power to the request context
+// and starts a "http.server" span for every request.
+// The route function names the route of the request, the URL path is used when it is nil.
+// A panic in the handler is recovered as ErrHTTPPanic, sent as a "http.server.panic" event
+// and answered with 500 Internal Server Error when nothing has been written yet.
+// A panic with http.ErrAbortHandler is sent as the event too, then panics again to abort the response.
+// Example:
+//
+//	mux := http.NewServeMux()
+//	mux.HandleFunc("/users", handleUsers)
+//
+//	handler := talker.HTTPMiddleware(pwr, nil)(mux)
+//	http.ListenAndServe(":8080", handler)
+func HTTPMiddleware(pwr Power, route func(*http.Request) string) func(http.Handler) http.Handler {
+	if route == nil {
+		route = func(r *http.Request) string {
+			return r.URL.Path
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			ctx := pwr.Context(r.Context(), "")
+			ctx, end := Span(ctx, "http.server", Params{
+				"http.request.method": r.Method,
+				"http.route":          route(r),
+				"url.path":            r.URL.Path,
+			})
+
+			defer end()
+
+			sw := &statusWriter{ResponseWriter: w}
+			panicErr := ErrHTTPPanic
+			panicked := true
+			aborted := false
+
+			func() {
+				defer RecoverAs(&panicErr, 32)
+				defer func() {
+					if recovered := recover(); recovered != nil {
+						if recovered == http.ErrAbortHandler {
+							aborted = true
+							return
+						}
+
+						panic(recovered) // recovered by RecoverAs, with the stack of the handler
+					}
+				}()
+
+				next.ServeHTTP(sw, r.WithContext(ctx))
+				panicked = false
+			}()
+
+			if aborted {
+				Event(ctx, "http.server.panic", Params{"error": ErrorDataFrom(ErrHTTPPanic.WithInfo(http.ErrAbortHandler.Error()), 32)})
+				panic(http.ErrAbortHandler)
+			}
+
+			if panicked {
+				Event(ctx, "http.server.panic", Params{"error": ErrorDataFrom(panicErr, 32)})
+
+				if !sw.wroteHeader {
+					http.Error(sw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+			}
+
+			SpanFromContext(ctx).SetParams(Params{
+				"http.response.status_code": sw.statusCode(),
+				"http.response.body.size":   sw.size,
+			})
+
+			Event(ctx, "http.server.response", Params{
+				"http.response.status_code": sw.statusCode(),
+				"http.response.body.size":   sw.size,
+				"duration_ms":               time.Since(start).Milliseconds(),
+			})
+		})
+	}
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	size        int64
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+
+	return n, err
+}
+
+func (w *statusWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the original http.ResponseWriter, it is used by http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *statusWriter) statusCode() int {
+	if !w.wroteHeader {
+		return http.StatusOK
+	}
+
+	return w.status
+}
+
+// Transport is a http.RoundTripper that starts a "http.client" span for every outgoing request.
+// The span uses the Power in the request context, so requests made inside a handler
+// wrapped by HTTPMiddleware are traced as children of the server span.
+// Example:
+//
+//	client := &http.Client{Transport: talker.NewTransport(nil)}
+//	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://example.com", nil)
+//	resp, err := client.Do(req)
+type Transport struct {
+	Base http.RoundTripper // Base is the underlying transport, http.DefaultTransport is used when it is nil.
+}
+
+var _ http.RoundTripper = &Transport{}
+
+// NewTransport creates a new Transport wrapping the given base transport.
+func NewTransport(base http.RoundTripper) *Transport {
+	return &Transport{Base: base}
+}
+
+// RoundTrip executes a single HTTP transaction inside a "http.client" span.
+// Failures are recorded with SpanHandle.RecordError and responses are sent as "http.client.response" events.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	start := time.Now()
+
+	ctx, end := Span(req.Context(), "http.client", Params{
+		"http.request.method": req.Method,
+		"server.address":      req.URL.Host,
+		"url.path":            req.URL.Path,
+	})
+
+	defer end()
+
+	resp, err := base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		SpanFromContext(ctx).RecordError(err)
+
+		return resp, err
+	}
+
+	SpanFromContext(ctx).SetParams(Params{"http.response.status_code": resp.StatusCode})
+
+	Event(ctx, "http.client.response", Params{
+		"http.response.status_code": resp.StatusCode,
+		"duration_ms":               time.Since(start).Milliseconds(),
+	})
+
+	return resp, nil
+}
@@ -0,0 +1,177 @@
+package talker_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/Arsfiqball/csverse/talker"
+)
+
+type recordedEvent struct {
+	name  string
+	attrs map[string]any
+}
+
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []recordedEvent
+}
+
+func (r *eventRecorder) hook(ctx context.Context, name string, attrs map[string]any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, recordedEvent{name: name, attrs: attrs})
+}
+
+func (r *eventRecorder) find(name string) (recordedEvent, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, e := range r.events {
+		if e.name == name {
+			return e, true
+		}
+	}
+
+	return recordedEvent{}, false
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	t.Run("response", func(t *testing.T) {
+		rec := &eventRecorder{}
+		spans := []string{}
+
+		pwr := talker.NewPower().
+			WithEventHook(rec.hook).
+			WithSpanHook(func(ctx context.Context, name string, attrs map[string]any) (context.Context, func()) {
+				spans = append(spans, name+" "+attrs["http.route"].(string))
+				return ctx, func() {}
+			})
+
+		handler := talker.HTTPMiddleware(pwr, func(r *http.Request) string { return "/users/{id}" })(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte("hello"))
+			}),
+		)
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/users/1", nil))
+
+		if len(spans) != 1 || spans[0] != "http.server /users/{id}" {
+			t.Fatalf("unexpected spans: %v", spans)
+		}
+
+		e, ok := rec.find("http.server.response")
+		if !ok {
+			t.Fatal("response event is not sent")
+		}
+
+		if e.attrs["http.response.status_code"] != http.StatusCreated || e.attrs["http.response.body.size"] != int64(5) {
+			t.Fatalf("unexpected response params: %v", e.attrs)
+		}
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		rec := &eventRecorder{}
+		pwr := talker.NewPower().WithEventHook(rec.hook)
+
+		handler := talker.HTTPMiddleware(pwr, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+		if w.Code != http.StatusInternalServerError {
+			t.Fatal("panic is not answered with 500")
+		}
+
+		e, ok := rec.find("http.server.panic")
+		if !ok {
+			t.Fatal("panic event is not sent")
+		}
+
+		errs := e.attrs["error"].([]talker.ErrorData)
+		if len(errs) == 0 || errs[len(errs)-1].Info != "boom" {
+			t.Fatalf("panic is not recovered as talker.Error: %v", errs)
+		}
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		rec := &eventRecorder{}
+		pwr := talker.NewPower().WithEventHook(rec.hook)
+
+		handler := talker.HTTPMiddleware(pwr, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}))
+
+		w := httptest.NewRecorder()
+
+		func() {
+			defer func() {
+				if recovered := recover(); recovered != http.ErrAbortHandler {
+					t.Fatalf("abort is not panicked again: %v", recovered)
+				}
+			}()
+
+			handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		}()
+
+		if w.Code == http.StatusInternalServerError {
+			t.Fatal("abort is answered with 500")
+		}
+
+		if _, ok := rec.find("http.server.panic"); !ok {
+			t.Fatal("panic event is not sent")
+		}
+	})
+}
+
+func TestTransport(t *testing.T) {
+	rec := &eventRecorder{}
+	pwr := talker.NewPower().WithEventHook(rec.hook)
+	ctx := pwr.Context(context.Background(), "test")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: talker.NewTransport(nil)}
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	e, ok := rec.find("http.client.response")
+	if !ok || e.attrs["http.response.status_code"] != http.StatusTeapot {
+		t.Fatal("response event is not sent")
+	}
+
+	failing := &http.Client{Transport: talker.NewTransport(roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return nil, errors.New("refused")
+	}))}
+
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	if _, err := failing.Do(req); err == nil {
+		t.Fatal("error is not returned")
+	}
+
+	if _, ok := rec.find("http.client.error"); !ok {
+		t.Fatal("error event is not sent")
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
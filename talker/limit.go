@@ -0,0 +1,235 @@
+package talker
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is the error returned when a call is rejected by a rate limiter,
+// the data of the error is the time.Duration until a token is available.
+var ErrRateLimited = NewError("RATE_LIMITED", "rate limit exceeded")
+
+// ErrBulkheadFull is the error returned when a call is rejected by a bulkhead.
+var ErrBulkheadFull = NewError("BULKHEAD_FULL", "bulkhead is full")
+
+// RateLimitMode decides what happens when there is no token available.
+type RateLimitMode int
+
+const (
+	RejectWhenLimited RateLimitMode = iota // RejectWhenLimited returns ErrRateLimited immediately.
+	WaitWhenLimited                        // WaitWhenLimited waits for a token, unless the context deadline comes first.
+)
+
+// RateLimitOptions is the configuration for the RateLimit function.
+type RateLimitOptions struct {
+	Rate  float64       // Rate is the number of calls allowed per second.
+	Burst int           // Burst is the size of the bucket, default is Rate rounded up (at least 1).
+	Mode  RateLimitMode // Mode decides what happens when there is no token available, default is RejectWhenLimited.
+}
+
+// RateLimiter is a token bucket that can wrap many callbacks.
+// This struct must be created with the NewRateLimiter function.
+type RateLimiter struct {
+	opts RateLimitOptions
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a new RateLimiter, the bucket starts full.
+// It panics when the rate is not positive.
+func NewRateLimiter(opts RateLimitOptions) *RateLimiter {
+	if opts.Rate <= 0 {
+		panic("talker: rate limit must be positive")
+	}
+
+	if opts.Burst <= 0 {
+		opts.Burst = int(math.Ceil(opts.Rate))
+	}
+
+	return &RateLimiter{opts: opts, tokens: float64(opts.Burst), last: time.Now()}
+}
+
+// Wrap returns a Callback that takes a token before running callback.
+func (l *RateLimiter) Wrap(callback Callback) Callback {
+	return func(ctx context.Context) error {
+		if err := l.wait(ctx); err != nil {
+			return err
+		}
+
+		return callback(ctx)
+	}
+}
+
+// reserve takes a token, the bucket can go below zero when the caller is going to wait.
+// It returns how long the caller has to wait for the token.
+func (l *RateLimiter) reserve(ctx context.Context) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+
+	l.tokens = math.Min(float64(l.opts.Burst), l.tokens+now.Sub(l.last).Seconds()*l.opts.Rate)
+	l.last = now
+
+	if l.tokens >= 1 {
+		l.tokens--
+		return 0, nil
+	}
+
+	delay := time.Duration((1 - l.tokens) / l.opts.Rate * float64(time.Second))
+
+	if l.opts.Mode == RejectWhenLimited {
+		return 0, ErrRateLimited.WithData(delay)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(now.Add(delay)) {
+		return 0, ErrRateLimited.
+			WithInfo(fmt.Sprintf("rate limit exceeded, a token is available in %s, after the context deadline", delay)).
+			WithData(delay)
+	}
+
+	l.tokens--
+
+	return delay, nil
+}
+
+func (l *RateLimiter) wait(ctx context.Context) error {
+	delay, err := l.reserve(ctx)
+	if err != nil || delay == 0 {
+		return err
+	}
+
+	if err := sleepContext(ctx, delay); err != nil {
+		l.mu.Lock()
+		l.tokens++ // give the token back
+		l.mu.Unlock()
+
+		return ErrRateLimited.Wrap(err).WithData(delay)
+	}
+
+	return nil
+}
+
+// RateLimit runs callback when a token of the bucket is available.
+// The bucket belongs to the returned Callback, use NewRateLimiter to share it with other callbacks.
+// Example:
+//
+//	search := talker.RateLimit(
+//		func(ctx context.Context) error {
+//			// ... call the search API
+//			return nil
+//		},
+//		talker.RateLimitOptions{Rate: 10, Burst: 20, Mode: talker.WaitWhenLimited},
+//	)
+//
+//	err := search(ctx) // errors.Is(err, talker.ErrRateLimited) when the deadline comes before a token
+func RateLimit(callback Callback, opts RateLimitOptions) Callback {
+	return NewRateLimiter(opts).Wrap(callback)
+}
+
+// BulkheadOptions is the configuration for the Bulkhead function.
+type BulkheadOptions struct {
+	MaxConcurrent int           // MaxConcurrent is the maximum number of running calls, default is 1.
+	MaxQueue      int           // MaxQueue is the maximum number of calls waiting for a slot, calls are not queued when it is 0.
+	MaxWait       time.Duration // MaxWait is the maximum time a call waits in the queue, calls wait until the context is done when it is 0.
+}
+
+// BulkheadLimiter caps the number of running calls, and can wrap many callbacks.
+// This struct must be created with the NewBulkheadLimiter function.
+type BulkheadLimiter struct {
+	opts  BulkheadOptions
+	slots chan struct{}
+
+	mu     sync.Mutex
+	queued int
+}
+
+// NewBulkheadLimiter creates a new BulkheadLimiter.
+func NewBulkheadLimiter(opts BulkheadOptions) *BulkheadLimiter {
+	if opts.MaxConcurrent <= 0 {
+		opts.MaxConcurrent = 1
+	}
+
+	return &BulkheadLimiter{opts: opts, slots: make(chan struct{}, opts.MaxConcurrent)}
+}
+
+// Wrap returns a Callback that runs callback in a slot of the bulkhead.
+func (b *BulkheadLimiter) Wrap(callback Callback) Callback {
+	return func(ctx context.Context) error {
+		if err := b.acquire(ctx); err != nil {
+			return err
+		}
+
+		defer func() { <-b.slots }()
+
+		return callback(ctx)
+	}
+}
+
+// InFlight returns the number of running calls.
+func (b *BulkheadLimiter) InFlight() int {
+	return len(b.slots)
+}
+
+func (b *BulkheadLimiter) acquire(ctx context.Context) error {
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	b.mu.Lock()
+	if b.queued >= b.opts.MaxQueue {
+		b.mu.Unlock()
+		return ErrBulkheadFull.WithInfo(fmt.Sprintf("bulkhead is full, %d calls are running", b.opts.MaxConcurrent))
+	}
+
+	b.queued++
+	b.mu.Unlock()
+
+	defer func() {
+		b.mu.Lock()
+		b.queued--
+		b.mu.Unlock()
+	}()
+
+	var timeout <-chan time.Time
+
+	if b.opts.MaxWait > 0 {
+		timer := time.NewTimer(b.opts.MaxWait)
+		defer timer.Stop()
+
+		timeout = timer.C
+	}
+
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	case <-timeout:
+		return ErrBulkheadFull.WithInfo(fmt.Sprintf("bulkhead is full, no slot is available after %s", b.opts.MaxWait))
+	case <-ctx.Done():
+		return ErrBulkheadFull.Wrap(ctx.Err())
+	}
+}
+
+// Bulkhead runs callback when one of the slots of the bulkhead is free.
+// The bulkhead belongs to the returned Callback, use NewBulkheadLimiter to share it with other callbacks.
+// Example:
+//
+//	report := talker.Bulkhead(
+//		func(ctx context.Context) error {
+//			// ... run an expensive query
+//			return nil
+//		},
+//		talker.BulkheadOptions{MaxConcurrent: 4, MaxQueue: 100, MaxWait: 5 * time.Second},
+//	)
+//
+//	err := report(ctx) // errors.Is(err, talker.ErrBulkheadFull) when it is rejected
+func Bulkhead(callback Callback, opts BulkheadOptions) Callback {
+	return NewBulkheadLimiter(opts).Wrap(callback)
+}
@@ -0,0 +1,212 @@
+package talker_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Arsfiqball/csverse/talker"
+)
+
+func TestRateLimit(t *testing.T) {
+	noop := func(ctx context.Context) error { return nil }
+
+	t.Run("reject", func(t *testing.T) {
+		cb := talker.RateLimit(noop, talker.RateLimitOptions{Rate: 10, Burst: 2})
+
+		for i := 0; i < 2; i++ {
+			if err := cb(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		err := cb(context.Background())
+		if !errors.Is(err, talker.ErrRateLimited) {
+			t.Fatalf("call is not rejected: %v", err)
+		}
+
+		var limitErr talker.Error
+		if !errors.As(err, &limitErr) || limitErr.Data().(time.Duration) <= 0 {
+			t.Fatal("delay is not set as data")
+		}
+
+		time.Sleep(110 * time.Millisecond)
+
+		if err := cb(context.Background()); err != nil {
+			t.Fatal("bucket is not refilled")
+		}
+	})
+
+	t.Run("wait", func(t *testing.T) {
+		cb := talker.RateLimit(noop, talker.RateLimitOptions{Rate: 50, Burst: 1, Mode: talker.WaitWhenLimited})
+		start := time.Now()
+
+		for i := 0; i < 3; i++ {
+			if err := cb(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if elapsed := time.Since(start); elapsed < 35*time.Millisecond {
+			t.Fatalf("calls are not limited: %s", elapsed)
+		}
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		cb := talker.RateLimit(noop, talker.RateLimitOptions{Rate: 1, Mode: talker.WaitWhenLimited})
+
+		if err := cb(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+
+		if err := cb(ctx); !errors.Is(err, talker.ErrRateLimited) {
+			t.Fatalf("call is not rejected: %v", err)
+		}
+
+		if time.Since(start) > 5*time.Millisecond {
+			t.Fatal("call waited although the deadline comes first")
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		cb := talker.RateLimit(noop, talker.RateLimitOptions{Rate: 1, Mode: talker.WaitWhenLimited})
+
+		if err := cb(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+		}()
+
+		if err := cb(ctx); !errors.Is(err, talker.ErrRateLimited) || !errors.Is(err, context.Canceled) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestBulkhead(t *testing.T) {
+	t.Run("max concurrent", func(t *testing.T) {
+		var (
+			mu      sync.Mutex
+			running int
+			peak    int
+			wg      sync.WaitGroup
+		)
+
+		cb := talker.Bulkhead(func(ctx context.Context) error {
+			mu.Lock()
+			running++
+			if running > peak {
+				peak = running
+			}
+			mu.Unlock()
+
+			time.Sleep(5 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+
+			return nil
+		}, talker.BulkheadOptions{MaxConcurrent: 2, MaxQueue: 10})
+
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				if err := cb(context.Background()); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+
+		wg.Wait()
+
+		if peak != 2 {
+			t.Fatalf("unexpected peak: %d", peak)
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		bulkhead := talker.NewBulkheadLimiter(talker.BulkheadOptions{MaxConcurrent: 1, MaxQueue: 1, MaxWait: 20 * time.Millisecond})
+		release := make(chan struct{})
+		done := make(chan error)
+
+		go func() {
+			done <- bulkhead.Wrap(func(ctx context.Context) error {
+				<-release
+				return nil
+			})(context.Background())
+		}()
+
+		time.Sleep(5 * time.Millisecond)
+
+		if bulkhead.InFlight() != 1 {
+			t.Fatalf("unexpected in-flight calls: %d", bulkhead.InFlight())
+		}
+
+		queued := make(chan error)
+		noop := bulkhead.Wrap(func(ctx context.Context) error { return nil })
+
+		go func() {
+			queued <- noop(context.Background())
+		}()
+
+		time.Sleep(5 * time.Millisecond)
+
+		if err := noop(context.Background()); !errors.Is(err, talker.ErrBulkheadFull) {
+			t.Fatalf("call is not rejected when the queue is full: %v", err)
+		}
+
+		if err := <-queued; !errors.Is(err, talker.ErrBulkheadFull) {
+			t.Fatalf("call is not rejected after the max wait: %v", err)
+		}
+
+		close(release)
+
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+
+		if err := noop(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		bulkhead := talker.NewBulkheadLimiter(talker.BulkheadOptions{MaxConcurrent: 1, MaxQueue: 1})
+		release := make(chan struct{})
+
+		go func() {
+			_ = bulkhead.Wrap(func(ctx context.Context) error {
+				<-release
+				return nil
+			})(context.Background())
+		}()
+
+		defer close(release)
+
+		time.Sleep(5 * time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := bulkhead.Wrap(func(ctx context.Context) error { return nil })(ctx)
+		if !errors.Is(err, talker.ErrBulkheadFull) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
@@ -0,0 +1,368 @@
+package talker
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetricKind is the kind of a metric sent with the Counter, Gauge and Histogram functions.
+type MetricKind int
+
+const (
+	CounterMetric   MetricKind = iota // CounterMetric is a monotonically increasing value.
+	GaugeMetric                       // GaugeMetric is a value that can go up and down.
+	HistogramMetric                   // HistogramMetric is an observation that is aggregated into buckets.
+)
+
+// String returns the Prometheus type name of the metric kind.
+func (k MetricKind) String() string {
+	switch k {
+	case CounterMetric:
+		return "counter"
+	case GaugeMetric:
+		return "gauge"
+	case HistogramMetric:
+		return "histogram"
+	}
+
+	return "untyped"
+}
+
+// MetricHook is a function that can be used to hook into the Counter, Gauge and Histogram functions.
+type MetricHook func(ctx context.Context, kind MetricKind, name string, value float64, attrs map[string]any)
+
+// Counter adds the given value to a counter with the given name and attributes.
+// Example:
+//
+//	func doSomething(ctx context.Context) {
+//		talker.Counter(ctx, "jobs_total", 1, talker.Params{"queue": "default"})
+//		// ... do something
+//	}
+func Counter(ctx context.Context, name string, value float64, attrs map[string]any) {
+	metric(ctx, CounterMetric, name, value, attrs)
+}
+
+// Gauge sets a gauge with the given name and attributes to the given value.
+// Example:
+//
+//	func doSomething(ctx context.Context) {
+//		talker.Gauge(ctx, "queue_size", float64(len(queue)), nil)
+//		// ... do something
+//	}
+func Gauge(ctx context.Context, name string, value float64, attrs map[string]any) {
+	metric(ctx, GaugeMetric, name, value, attrs)
+}
+
+// Histogram records an observation in a histogram with the given name and attributes.
+// Example:
+//
+//	func doSomething(ctx context.Context) {
+//		start := time.Now()
+//		// ... do something
+//		talker.Histogram(ctx, "do_something_seconds", time.Since(start).Seconds(), nil)
+//	}
+func Histogram(ctx context.Context, name string, value float64, attrs map[string]any) {
+	metric(ctx, HistogramMetric, name, value, attrs)
+}
+
+func metric(ctx context.Context, kind MetricKind, name string, value float64, attrs map[string]any) {
+	pwr, ok := ctx.Value(powerContextKey).(Power)
+	if !ok {
+		return
+	}
+
+	for _, hook := range pwr.metricHooks {
+		pwr.callMetricHook(hook, ctx, kind, name, value, attrs)
+	}
+}
+
+// SpanDurationHook returns a SpanHook that records the duration of every span in seconds
+// as a Histogram with the given metric name and a "span" attribute.
+// If the metric name is empty, "span_duration_seconds" is used.
+// Example:
+//
+//	store := talker.NewMetricStore()
+//	pwr := talker.NewPower().
+//		WithMetricHook(store.Hook()).
+//		WithSpanHook(talker.SpanDurationHook(""))
+func SpanDurationHook(metricName string) SpanHook {
+	if metricName == "" {
+		metricName = "span_duration_seconds"
+	}
+
+	return func(ctx context.Context, name string, attrs map[string]any) (context.Context, func()) {
+		start := time.Now()
+
+		return ctx, func() {
+			Histogram(ctx, metricName, time.Since(start).Seconds(), map[string]any{"span": name})
+		}
+	}
+}
+
+// DefaultBuckets are the histogram buckets used by NewMetricStore when no buckets are given.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// MetricStore is an in-memory aggregator for metrics.
+// It can be exposed in the Prometheus text format, either with WritePrometheus
+// or by setting it as Process.Metrics so the monitor server serves it at /metrics.
+// This store must be created with the NewMetricStore function.
+type MetricStore struct {
+	mu       sync.Mutex
+	buckets  []float64
+	families map[string]*metricFamily
+}
+
+type metricFamily struct {
+	kind   MetricKind
+	series map[string]*metricSeries
+}
+
+type metricSeries struct {
+	labels string
+	value  float64
+	counts []uint64
+	count  uint64
+}
+
+// NewMetricStore creates a new MetricStore.
+// The buckets are used for every histogram, DefaultBuckets is used if none are given.
+func NewMetricStore(buckets ...float64) *MetricStore {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	sorted := append([]float64{}, buckets...)
+	sort.Float64s(sorted)
+
+	return &MetricStore{buckets: sorted, families: map[string]*metricFamily{}}
+}
+
+// Hook returns a MetricHook that records metrics in the store.
+func (s *MetricStore) Hook() MetricHook {
+	return func(ctx context.Context, kind MetricKind, name string, value float64, attrs map[string]any) {
+		s.Record(kind, name, value, attrs)
+	}
+}
+
+// Record records a metric in the store.
+// A metric that is recorded with a different kind than the first time is ignored,
+// and so are negative counter increments.
+func (s *MetricStore) Record(kind MetricKind, name string, value float64, attrs map[string]any) {
+	if kind == CounterMetric && value < 0 {
+		return
+	}
+
+	name = sanitizeMetricName(name)
+	labels := formatMetricLabels(attrs)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	family, ok := s.families[name]
+	if !ok {
+		family = &metricFamily{kind: kind, series: map[string]*metricSeries{}}
+		s.families[name] = family
+	}
+
+	if family.kind != kind {
+		return
+	}
+
+	series, ok := family.series[labels]
+	if !ok {
+		series = &metricSeries{labels: labels}
+
+		if kind == HistogramMetric {
+			series.counts = make([]uint64, len(s.buckets))
+		}
+
+		family.series[labels] = series
+	}
+
+	switch kind {
+	case CounterMetric:
+		series.value += value
+	case GaugeMetric:
+		series.value = value
+	case HistogramMetric:
+		series.value += value
+		series.count++
+
+		for i, bound := range s.buckets {
+			if value <= bound {
+				series.counts[i]++
+			}
+		}
+	}
+}
+
+type metricFamilySnapshot struct {
+	name   string
+	kind   MetricKind
+	series []metricSeries
+}
+
+// snapshot copies the families sorted by name, with their series sorted by labels.
+func (s *MetricStore) snapshot() []metricFamilySnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	families := make([]metricFamilySnapshot, 0, len(s.families))
+
+	for name, family := range s.families {
+		snapshot := metricFamilySnapshot{name: name, kind: family.kind, series: make([]metricSeries, 0, len(family.series))}
+
+		for _, series := range family.series {
+			copied := *series
+			copied.counts = append([]uint64(nil), series.counts...)
+			snapshot.series = append(snapshot.series, copied)
+		}
+
+		sort.Slice(snapshot.series, func(i, j int) bool { return snapshot.series[i].labels < snapshot.series[j].labels })
+
+		families = append(families, snapshot)
+	}
+
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+
+	return families
+}
+
+// WritePrometheus writes all metrics in the Prometheus text exposition format.
+// The metrics are copied first, so a slow writer does not block recording.
+func (s *MetricStore) WritePrometheus(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	for _, family := range s.snapshot() {
+		name := family.name
+
+		fmt.Fprintf(bw, "# TYPE %s %s\n", name, family.kind)
+
+		for _, series := range family.series {
+			if family.kind != HistogramMetric {
+				fmt.Fprintf(bw, "%s%s %s\n", name, wrapMetricLabels(series.labels), formatMetricValue(series.value))
+				continue
+			}
+
+			for i, bound := range s.buckets {
+				le := `le="` + formatMetricValue(bound) + `"`
+				fmt.Fprintf(bw, "%s_bucket%s %d\n", name, wrapMetricLabels(joinMetricLabels(series.labels, le)), series.counts[i])
+			}
+
+			fmt.Fprintf(bw, "%s_bucket%s %d\n", name, wrapMetricLabels(joinMetricLabels(series.labels, `le="+Inf"`)), series.count)
+			fmt.Fprintf(bw, "%s_sum%s %s\n", name, wrapMetricLabels(series.labels), formatMetricValue(series.value))
+			fmt.Fprintf(bw, "%s_count%s %d\n", name, wrapMetricLabels(series.labels), series.count)
+		}
+	}
+
+	return bw.Flush()
+}
+
+// ServeHTTP serves the metrics in the Prometheus text exposition format.
+func (s *MetricStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	if err := s.WritePrometheus(w); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func sanitizeMetricName(name string) string {
+	var b strings.Builder
+
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_', r == ':':
+			b.WriteRune(r)
+		case r >= '0' && r <= '9' && i > 0:
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+
+	return b.String()
+}
+
+// sanitizeMetricLabel returns a valid label name, [a-zA-Z_][a-zA-Z0-9_]*, unlike metric names it can not contain ":".
+func sanitizeMetricLabel(name string) string {
+	if name == "" {
+		return "_"
+	}
+
+	return strings.ReplaceAll(sanitizeMetricName(name), ":", "_")
+}
+
+// formatMetricLabels formats the attrs as sorted labels.
+// When attrs sanitize to the same label name, e.g. "a.b" and "a_b", the last key in sorted order wins.
+func formatMetricLabels(attrs map[string]any) string {
+	if len(attrs) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(attrs))
+	for key := range attrs {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	values := make(map[string]any, len(keys))
+	for _, key := range keys {
+		values[sanitizeMetricLabel(key)] = attrs[key]
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+	for i, name := range names {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, name, replacer.Replace(fmt.Sprint(values[name])))
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+func joinMetricLabels(labels string, extra string) string {
+	if labels == "" {
+		return extra
+	}
+
+	return labels + "," + extra
+}
+
+func wrapMetricLabels(labels string) string {
+	if labels == "" {
+		return ""
+	}
+
+	return "{" + labels + "}"
+}
+
+func formatMetricValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
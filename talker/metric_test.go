@@ -0,0 +1,130 @@
+package talker_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Arsfiqball/csverse/talker"
+)
+
+func TestMetric(t *testing.T) {
+	t.Run("without power", func(t *testing.T) {
+		// Should not panic when there is no Power in the context
+		talker.Counter(context.Background(), "noop_total", 1, nil)
+	})
+
+	t.Run("prometheus", func(t *testing.T) {
+		store := talker.NewMetricStore(1, 5)
+		ctx := talker.NewPower().WithMetricHook(store.Hook()).Context(context.Background(), "test")
+
+		talker.Counter(ctx, "jobs_total", 1, talker.Params{"queue": "default"})
+		talker.Counter(ctx, "jobs_total", 2, talker.Params{"queue": "default"})
+		talker.Counter(ctx, "jobs_total", -1, talker.Params{"queue": "default"}) // ignored
+		talker.Gauge(ctx, "queue.size", 7, nil)
+		talker.Gauge(ctx, "queue.size", 3, nil)
+		talker.Histogram(ctx, "latency_seconds", 0.5, nil)
+		talker.Histogram(ctx, "latency_seconds", 3, nil)
+		talker.Histogram(ctx, "latency_seconds", 10, nil)
+
+		rec := httptest.NewRecorder()
+		store.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+		expected := strings.Join([]string{
+			`# TYPE jobs_total counter`,
+			`jobs_total{queue="default"} 3`,
+			`# TYPE latency_seconds histogram`,
+			`latency_seconds_bucket{le="1"} 1`,
+			`latency_seconds_bucket{le="5"} 2`,
+			`latency_seconds_bucket{le="+Inf"} 3`,
+			`latency_seconds_sum 13.5`,
+			`latency_seconds_count 3`,
+			`# TYPE queue_size gauge`,
+			`queue_size 3`,
+		}, "\n") + "\n"
+
+		if rec.Body.String() != expected {
+			t.Fatalf("unexpected output:\n%s", rec.Body.String())
+		}
+	})
+
+	t.Run("label names", func(t *testing.T) {
+		store := talker.NewMetricStore()
+		store.Record(talker.GaugeMetric, "queue_size", 1, map[string]any{"a:b": "x", "a.c": "y", "a_c": "z", "9lives": "w"})
+
+		var b strings.Builder
+		if err := store.WritePrometheus(&b); err != nil {
+			t.Fatal(err)
+		}
+
+		expected := "# TYPE queue_size gauge\n" + `queue_size{_lives="w",a_b="x",a_c="z"} 1` + "\n"
+
+		if b.String() != expected {
+			t.Fatalf("unexpected output:\n%s", b.String())
+		}
+	})
+
+	t.Run("slow scraper", func(t *testing.T) {
+		store := talker.NewMetricStore()
+		store.Record(talker.CounterMetric, "jobs_total", 1, nil)
+
+		w := &blockingWriter{started: make(chan struct{}), release: make(chan struct{})}
+		done := make(chan error, 1)
+
+		go func() { done <- store.WritePrometheus(w) }()
+
+		<-w.started
+
+		recorded := make(chan struct{})
+
+		go func() {
+			store.Record(talker.CounterMetric, "jobs_total", 1, nil)
+			close(recorded)
+		}()
+
+		select {
+		case <-recorded:
+		case <-time.After(time.Second):
+			t.Fatal("recording is blocked by the writer")
+		}
+
+		close(w.release)
+
+		if err := <-done; err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("span duration", func(t *testing.T) {
+		store := talker.NewMetricStore()
+		pwr := talker.NewPower().
+			WithMetricHook(store.Hook()).
+			WithSpanHook(talker.SpanDurationHook(""))
+
+		_, end := talker.Span(pwr.Context(context.Background(), "test"), "work", nil)
+		end()
+
+		var b strings.Builder
+		if err := store.WritePrometheus(&b); err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(b.String(), `span_duration_seconds_count{span="work"} 1`) {
+			t.Fatalf("span duration is not recorded:\n%s", b.String())
+		}
+	})
+}
+
+type blockingWriter struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (w *blockingWriter) Write(b []byte) (int, error) {
+	close(w.started)
+	<-w.release
+
+	return len(b), nil
+}
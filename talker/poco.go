@@ -237,18 +237,32 @@ type Params map[string]any
 func Span(ctx context.Context, name string, params Params) (context.Context, func()) {
 	pwr, ok := ctx.Value(powerContextKey).(Power)
 	if !ok {
-		return ctx, func() {}
+		return ctx, noopEnd
 	}
 
+	if pwr.spanSampler != nil {
+		sampled := pwr.spanSampler(ctx, name)
+		ctx = withSamplingDecision(ctx, sampled)
+
+		if !sampled {
+			return ctx, noopEnd
+		}
+	}
+
+	params = mergeParams(ctx, params)
+	ctx, state := startSpanState(ctx, pwr, name, params)
+
 	var ends []func()
 
 	for _, hook := range pwr.spanHooks {
-		newCtx, end := hook(ctx, name, params)
+		newCtx, end := pwr.callSpanHook(hook, ctx, name, params)
 
 		ends = append(ends, end)
 		ctx = newCtx
 	}
 
+	state.setContext(ctx) // the span handle methods see the values added by the hooks, e.g. a backend span
+
 	return ctx, func() {
 		for _, end := range ends {
 			end()
@@ -256,6 +270,8 @@ func Span(ctx context.Context, name string, params Params) (context.Context, fun
 	}
 }
 
+func noopEnd() {}
+
 // Event sends an event with the given name and attributes.
 // Example:
 //
@@ -269,8 +285,14 @@ func Event(ctx context.Context, name string, attrs map[string]any) {
 		return
 	}
 
+	if pwr.eventSampler != nil && !pwr.eventSampler(ctx, name) {
+		return
+	}
+
+	attrs = mergeParams(ctx, attrs)
+
 	for _, hook := range pwr.eventHooks {
-		hook(ctx, name, attrs)
+		pwr.callEventHook(hook, ctx, name, attrs)
 	}
 }
 
@@ -280,16 +302,42 @@ type SpanHook func(ctx context.Context, name string, attrs map[string]any) (cont
 // EventHook is a function that can be used to hook into the Event function.
 type EventHook func(ctx context.Context, name string, attrs map[string]any)
 
-// Power is a configuration for the Span and Event functions.
+// Power is a configuration for the Span, Event, Counter, Gauge and Histogram functions.
 // This "Power" must be created with the NewPower function.
 type Power struct {
-	spanHooks  []SpanHook
-	eventHooks []EventHook
+	spanHooks    []SpanHook
+	eventHooks   []EventHook
+	metricHooks  []MetricHook
+	spanSampler  Sampler
+	eventSampler Sampler
+	resource     Resource
+	auditLog     *AuditLog
+
+	spanParamsHooks []SpanParamsHook
+	spanErrorHooks  []SpanErrorHook
+	spanLinkHooks   []SpanLinkHook
+	combinatorSpans bool
+
+	hookPanicHandler HookPanicHandler
+}
+
+// NewPower creates a new Power with the given options.
+// Example:
+//
+//	pwr := talker.NewPower(talker.WithResource(talker.Resource{ServiceName: "checkout", ServiceVersion: "1.2.3"}))
+func NewPower(opts ...PowerOption) Power {
+	pwr := Power{}
+
+	for _, opt := range opts {
+		pwr = opt(pwr)
+	}
+
+	return pwr
 }
 
-// NewPower creates a new Power.
-func NewPower() Power {
-	return Power{}
+// Resource returns the Resource of the Power.
+func (c Power) Resource() Resource {
+	return c.resource
 }
 
 // WithSpanHook adds a SpanHook to the Power.
@@ -306,12 +354,77 @@ func (c Power) WithEventHook(hook EventHook) Power {
 	return c
 }
 
+// WithMetricHook adds a MetricHook to the Power.
+func (c Power) WithMetricHook(hook MetricHook) Power {
+	c.metricHooks = append(c.metricHooks, hook)
+
+	return c
+}
+
+// WithSpanSampler sets the Sampler that decides which spans are recorded.
+// The decision is kept in the context, so child spans can follow it with ParentBasedSampler.
+// Dropped spans do not call any SpanHook.
+func (c Power) WithSpanSampler(sampler Sampler) Power {
+	c.spanSampler = sampler
+
+	return c
+}
+
+// WithEventSampler sets the Sampler that decides which events are recorded.
+// Dropped events do not call any EventHook.
+func (c Power) WithEventSampler(sampler Sampler) Power {
+	c.eventSampler = sampler
+
+	return c
+}
+
+// WithSpanParamsHook adds a SpanParamsHook to the Power.
+func (c Power) WithSpanParamsHook(hook SpanParamsHook) Power {
+	c.spanParamsHooks = append(c.spanParamsHooks, hook)
+
+	return c
+}
+
+// WithSpanErrorHook adds a SpanErrorHook to the Power.
+func (c Power) WithSpanErrorHook(hook SpanErrorHook) Power {
+	c.spanErrorHooks = append(c.spanErrorHooks, hook)
+
+	return c
+}
+
+// WithSpanLinkHook adds a SpanLinkHook to the Power.
+func (c Power) WithSpanLinkHook(hook SpanLinkHook) Power {
+	c.spanLinkHooks = append(c.spanLinkHooks, hook)
+
+	return c
+}
+
+// WithAuditLog sets the AuditLog used by the Audit function.
+func (c Power) WithAuditLog(log *AuditLog) Power {
+	c.auditLog = log
+
+	return c
+}
+
+// WithCombinatorSpans makes the Sequential, Parallel, Retry and Timeout combinators start a span
+// for themselves and a child span for every step or attempt, e.g. "talker.retry" and "talker.retry.attempt".
+func (c Power) WithCombinatorSpans() Power {
+	c.combinatorSpans = true
+
+	return c
+}
+
 // PowerContextKey is a context key for the Power.
 type PowerContextKey string
 
 const powerContextKey = PowerContextKey("power_context")
 
 // Context adds the Power to the context.
+// The name is used as the service name of the Resource when the Power has none.
 func (c Power) Context(ctx context.Context, name string) context.Context {
+	if c.resource.ServiceName == "" {
+		c.resource.ServiceName = name
+	}
+
 	return context.WithValue(ctx, powerContextKey, c)
 }
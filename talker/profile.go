@@ -0,0 +1,157 @@
+package talker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"runtime/metrics"
+	"runtime/pprof"
+	"runtime/trace"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProfileOptions is the configuration for the ProfileHook function.
+type ProfileOptions struct {
+	Labels      bool     // Labels sets pprof labels on the goroutine while the span runs, "span" is set to the span name.
+	LabelParams []string // LabelParams are the span params that are also set as pprof labels.
+}
+
+var profileSamples = []string{
+	"/gc/heap/allocs:bytes",
+	"/sched/goroutines:goroutines",
+	"/cpu/classes/total:cpu-seconds",
+}
+
+// ProfileHook returns a SpanHook that reads runtime/metrics when a span starts and ends,
+// and sets the differences as params of the span with SpanHandle.SetParams:
+// "runtime.alloc_bytes", "runtime.goroutines_delta" and "runtime.cpu_seconds".
+// The metrics are process wide, so concurrent spans see each other's work,
+// and the CPU time is an estimate that the runtime updates at garbage collections.
+// With the Labels option, CPU profiles taken from the monitor server at /debug/pprof/profile
+// can be split by span, e.g. with `go tool pprof -tagfocus span=checkout`.
+// Example:
+//
+//	pwr := talker.NewPower().WithSpanHook(talker.ProfileHook(talker.ProfileOptions{
+//		Labels:      true,
+//		LabelParams: []string{"http.route"},
+//	}))
+func ProfileHook(opts ProfileOptions) SpanHook {
+	return func(ctx context.Context, name string, attrs map[string]any) (context.Context, func()) {
+		span := SpanFromContext(ctx)
+		parentCtx := ctx
+
+		if opts.Labels {
+			labels := []string{"span", name}
+
+			for _, key := range opts.LabelParams {
+				if value, ok := attrs[key]; ok {
+					labels = append(labels, key, fmt.Sprint(value))
+				}
+			}
+
+			ctx = pprof.WithLabels(ctx, pprof.Labels(labels...))
+			pprof.SetGoroutineLabels(ctx)
+		}
+
+		before := readProfileSamples()
+
+		return ctx, func() {
+			after := readProfileSamples()
+
+			span.SetParams(Params{
+				"runtime.alloc_bytes":      after[0].Value.Uint64() - before[0].Value.Uint64(),
+				"runtime.goroutines_delta": int64(after[1].Value.Uint64()) - int64(before[1].Value.Uint64()),
+				"runtime.cpu_seconds":      after[2].Value.Float64() - before[2].Value.Float64(),
+			})
+
+			if opts.Labels {
+				pprof.SetGoroutineLabels(parentCtx)
+			}
+		}
+	}
+}
+
+func readProfileSamples() []metrics.Sample {
+	samples := make([]metrics.Sample, len(profileSamples))
+
+	for i, name := range profileSamples {
+		samples[i].Name = name
+	}
+
+	metrics.Read(samples)
+
+	return samples
+}
+
+// profileHandler serves the runtime/pprof profiles on the monitor server only, e.g. /debug/pprof/heap,
+// it does not use net/http/pprof since importing it registers the profiles on http.DefaultServeMux.
+// The "debug" query param writes the text format, e.g. /debug/pprof/goroutine?debug=2,
+// and "gc=1" runs a garbage collection before writing the heap profile.
+// A CPU profile of N seconds is served at /debug/pprof/profile?seconds=N,
+// and an execution trace of N seconds at /debug/pprof/trace?seconds=N.
+func profileHandler(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/debug/pprof/")
+	query := r.URL.Query()
+
+	switch name {
+	case "":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+
+		for _, profile := range pprof.Profiles() {
+			fmt.Fprintf(w, "%s %d\n", profile.Name(), profile.Count())
+		}
+
+		fmt.Fprintln(w, "profile")
+		fmt.Fprintln(w, "trace")
+	case "profile":
+		serveProfileDuration(w, r, 30, pprof.StartCPUProfile, pprof.StopCPUProfile)
+	case "trace":
+		serveProfileDuration(w, r, 1, trace.Start, trace.Stop)
+	default:
+		profile := pprof.Lookup(name)
+		if profile == nil {
+			http.Error(w, "unknown profile", http.StatusNotFound)
+			return
+		}
+
+		debug, _ := strconv.Atoi(query.Get("debug"))
+
+		if name == "heap" && query.Get("gc") != "" && query.Get("gc") != "0" {
+			runtime.GC()
+		}
+
+		if debug > 0 {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		} else {
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, name))
+		}
+
+		if err := profile.WriteTo(w, debug); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+// serveProfileDuration records for the number of seconds in the "seconds" query param, or the default.
+func serveProfileDuration(w http.ResponseWriter, r *http.Request, defaultSeconds int, start func(io.Writer) error, stop func()) {
+	seconds, err := strconv.Atoi(r.URL.Query().Get("seconds"))
+	if err != nil || seconds <= 0 {
+		seconds = defaultSeconds
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+
+	if err := start(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError) // e.g. another profile is running
+		return
+	}
+
+	_ = sleepContext(r.Context(), time.Duration(seconds)*time.Second)
+
+	stop()
+}
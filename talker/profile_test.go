@@ -0,0 +1,115 @@
+package talker_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime/pprof"
+	"strings"
+	"testing"
+
+	"github.com/Arsfiqball/csverse/talker"
+)
+
+func TestProfileHook(t *testing.T) {
+	var params talker.Params
+	var label string
+
+	pwr := talker.NewPower().
+		WithSpanHook(talker.ProfileHook(talker.ProfileOptions{Labels: true, LabelParams: []string{"route"}})).
+		WithSpanHook(func(ctx context.Context, name string, attrs map[string]any) (context.Context, func()) {
+			label, _ = pprof.Label(ctx, "route")
+			return ctx, func() {}
+		}).
+		WithSpanParamsHook(func(ctx context.Context, name string, p map[string]any) { params = p })
+
+	_, end := talker.Span(pwr.Context(context.Background(), "test"), "checkout", talker.Params{"route": "/checkout"})
+	end()
+
+	if label != "/checkout" {
+		t.Fatal("pprof label is not set")
+	}
+
+	if _, ok := params["runtime.alloc_bytes"].(uint64); !ok {
+		t.Fatalf("runtime params are not set: %v", params)
+	}
+
+	if _, ok := params["runtime.goroutines_delta"].(int64); !ok {
+		t.Fatal("goroutine delta is not set")
+	}
+
+	if _, ok := params["runtime.cpu_seconds"].(float64); !ok {
+		t.Fatal("cpu time is not set")
+	}
+}
+
+func TestProcessProfiling(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := listener.Addr().String()
+	listener.Close()
+
+	stop := make(chan os.Signal)
+	served := make(chan struct{})
+
+	go func() {
+		talker.Serve(talker.Process{
+			Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+			MonitorAddr: addr,
+			Profiling:   true,
+		}, stop)
+		close(served)
+	}()
+
+	defer func() {
+		close(stop)
+		<-served
+	}()
+
+	get := func(path string) (int, string) {
+		resp, err := http.Get("http://" + addr + path)
+		if err != nil {
+			return 0, err.Error()
+		}
+
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+
+		return resp.StatusCode, string(body)
+	}
+
+	waitFor(t, func() bool { status, _ := get("/live"); return status == http.StatusOK })
+
+	if status, body := get("/debug/pprof/"); status != http.StatusOK || !strings.Contains(body, "heap") {
+		t.Fatalf("profiles are not listed: %d %s", status, body)
+	}
+
+	if status, body := get("/debug/pprof/goroutine?debug=1"); status != http.StatusOK || !strings.Contains(body, "goroutine profile:") {
+		t.Fatalf("text profile is not served: %d %s", status, body)
+	}
+
+	if status, _ := get("/debug/pprof/heap?gc=1"); status != http.StatusOK {
+		t.Fatalf("heap profile is not served: %d", status)
+	}
+
+	if status, _ := get("/debug/pprof/trace?seconds=1"); status != http.StatusOK {
+		t.Fatalf("trace is not served: %d", status)
+	}
+
+	// The profiles are not exposed on the default mux, e.g. by http.ListenAndServe(addr, nil).
+	rec := httptest.NewRecorder()
+	http.DefaultServeMux.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/pprof/cmdline", nil))
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("profiles are served on the default mux: %d", rec.Code)
+	}
+
+}
@@ -0,0 +1,210 @@
+package talker
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	recordEvent     = "event"
+	recordSpanStart = "span_start"
+	recordSpanEnd   = "span_end"
+)
+
+type recordLine struct {
+	Time   time.Time      `json:"t"`
+	Kind   string         `json:"k"`
+	Name   string         `json:"n"`
+	Span   uint64         `json:"s,omitempty"`
+	Parent uint64         `json:"p,omitempty"`
+	Params map[string]any `json:"a,omitempty"`
+}
+
+// Recorder writes every Event and span boundary as JSON lines, so they can be replayed later with Replay.
+// Params that can not be written as JSON are formatted as strings.
+// This struct must be created with the NewRecorder function.
+type Recorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewRecorder creates a new Recorder that writes to w.
+// Example:
+//
+//	f, err := os.Create("events.jsonl")
+//	if err != nil {
+//		return err
+//	}
+//
+//	defer f.Close()
+//
+//	rec := talker.NewRecorder(f)
+//	pwr := rec.Install(talker.NewPower())
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{enc: json.NewEncoder(w)}
+}
+
+// Install returns the Power with the span and event hooks of the Recorder added.
+func (r *Recorder) Install(pwr Power) Power {
+	return pwr.WithSpanHook(r.SpanHook()).WithEventHook(r.EventHook())
+}
+
+// SpanHook returns a SpanHook that records the start and the end of every span.
+func (r *Recorder) SpanHook() SpanHook {
+	return func(ctx context.Context, name string, attrs map[string]any) (context.Context, func()) {
+		span := SpanFromContext(ctx)
+
+		r.write(recordLine{
+			Time:   time.Now(),
+			Kind:   recordSpanStart,
+			Name:   name,
+			Span:   span.ID(),
+			Parent: span.Parent().ID(),
+			Params: jsonParams(attrs),
+		})
+
+		return ctx, func() {
+			r.write(recordLine{Time: time.Now(), Kind: recordSpanEnd, Name: name, Span: span.ID()})
+		}
+	}
+}
+
+// EventHook returns an EventHook that records every event.
+func (r *Recorder) EventHook() EventHook {
+	return func(ctx context.Context, name string, attrs map[string]any) {
+		r.write(recordLine{
+			Time:   time.Now(),
+			Kind:   recordEvent,
+			Name:   name,
+			Span:   SpanFromContext(ctx).ID(),
+			Params: jsonParams(attrs),
+		})
+	}
+}
+
+// Err returns the first error that occurred while writing.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.err
+}
+
+func (r *Recorder) write(line recordLine) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return
+	}
+
+	r.err = r.enc.Encode(line)
+}
+
+// ReplayOptions is the configuration for the Replay function.
+type ReplayOptions struct {
+	Speed  float64     // Speed is the replay speed, 1 is real time, 10 is ten times faster. Default 0 replays as fast as possible.
+	Filter NameMatcher // Filter keeps the spans and events with a matching name, everything is kept when it is nil.
+}
+
+// Replay reads a stream written by a Recorder and sends every span and event through the hooks of the Power.
+// The spans keep their parent, and the events keep the span they were sent in.
+// Spans removed by the filter are skipped, their children are attached to the closest kept parent.
+// JSON decoding changes the type of some params, e.g. numbers become float64.
+// Example:
+//
+//	f, err := os.Open("events.jsonl")
+//	if err != nil {
+//		return err
+//	}
+//
+//	defer f.Close()
+//
+//	pwr := talker.NewPower().WithMetricHook(store.Hook()).WithSpanHook(talker.SpanDurationHook(""))
+//	err = talker.Replay(ctx, f, pwr, talker.ReplayOptions{Speed: 10, Filter: talker.MatchPrefix("http.")})
+func Replay(ctx context.Context, r io.Reader, pwr Power, opts ReplayOptions) error {
+	type openSpan struct {
+		ctx context.Context
+		end func()
+	}
+
+	ctx = pwr.Context(ctx, "")
+	spans := map[uint64]openSpan{}
+
+	defer func() {
+		for _, span := range spans {
+			if span.end != nil {
+				span.end()
+			}
+		}
+	}()
+
+	spanContext := func(id uint64) context.Context {
+		if span, ok := spans[id]; ok {
+			return span.ctx
+		}
+
+		return ctx
+	}
+
+	var first time.Time
+
+	start := time.Now()
+	dec := json.NewDecoder(bufio.NewReader(r))
+
+	for {
+		var line recordLine
+
+		if err := dec.Decode(&line); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if first.IsZero() {
+			first = line.Time
+		}
+
+		if opts.Speed > 0 {
+			due := time.Duration(float64(line.Time.Sub(first)) / opts.Speed)
+
+			if err := sleepContext(ctx, due-time.Since(start)); err != nil {
+				return err
+			}
+		} else if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		keep := opts.Filter == nil || opts.Filter(line.Name)
+
+		switch line.Kind {
+		case recordSpanStart:
+			parentCtx := spanContext(line.Parent)
+
+			if !keep {
+				spans[line.Span] = openSpan{ctx: parentCtx}
+				continue
+			}
+
+			spanCtx, end := Span(parentCtx, line.Name, line.Params)
+			spans[line.Span] = openSpan{ctx: spanCtx, end: end}
+		case recordSpanEnd:
+			if span, ok := spans[line.Span]; ok {
+				if span.end != nil {
+					span.end()
+				}
+
+				delete(spans, line.Span)
+			}
+		case recordEvent:
+			if keep {
+				Event(spanContext(line.Span), line.Name, line.Params)
+			}
+		}
+	}
+}
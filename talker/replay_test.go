@@ -0,0 +1,105 @@
+package talker_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Arsfiqball/csverse/talker"
+)
+
+func TestReplay(t *testing.T) {
+	var buf bytes.Buffer
+
+	rec := talker.NewRecorder(&buf)
+	ctx := rec.Install(talker.NewPower()).Context(context.Background(), "test")
+
+	ctx, end := talker.Span(ctx, "http.server", talker.Params{"route": "/users", "fn": func() {}})
+	talker.Event(ctx, "http.response", talker.Params{"status": 200})
+	dbCtx, endDB := talker.Span(ctx, "db.query", nil)
+	talker.Event(dbCtx, "db.rows", talker.Params{"rows": 3})
+	endDB()
+	end()
+
+	if rec.Err() != nil {
+		t.Fatal(rec.Err())
+	}
+
+	t.Run("all", func(t *testing.T) {
+		spans := &spanRecorder{}
+		events := &eventRecorder{}
+		var parents []string
+
+		pwr := talker.NewPower().
+			WithSpanHook(spans.hook).
+			WithEventHook(events.hook).
+			WithEventHook(func(ctx context.Context, name string, attrs map[string]any) {
+				parents = append(parents, talker.SpanFromContext(ctx).Name())
+			})
+
+		if err := talker.Replay(context.Background(), bytes.NewReader(buf.Bytes()), pwr, talker.ReplayOptions{}); err != nil {
+			t.Fatal(err)
+		}
+
+		if spans.sorted() != "db.query,http.server" {
+			t.Fatalf("unexpected spans: %s", spans.sorted())
+		}
+
+		e, ok := events.find("db.rows")
+		if !ok || e.attrs["rows"] != float64(3) {
+			t.Fatal("event is not replayed")
+		}
+
+		if len(parents) != 2 || parents[0] != "http.server" || parents[1] != "db.query" {
+			t.Fatalf("events are not replayed in their spans: %v", parents)
+		}
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		spans := &spanRecorder{}
+		var parents []string
+
+		pwr := talker.NewPower().
+			WithSpanHook(spans.hook).
+			WithEventHook(func(ctx context.Context, name string, attrs map[string]any) {
+				parents = append(parents, name+"@"+talker.SpanFromContext(ctx).Name())
+			})
+
+		err := talker.Replay(context.Background(), bytes.NewReader(buf.Bytes()), pwr, talker.ReplayOptions{
+			Filter: talker.MatchPrefix("http."),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if spans.sorted() != "http.server" {
+			t.Fatalf("unexpected spans: %s", spans.sorted())
+		}
+
+		if len(parents) != 1 || parents[0] != "http.response@http.server" {
+			t.Fatalf("unexpected events: %v", parents)
+		}
+	})
+
+	t.Run("speed", func(t *testing.T) {
+		var stream bytes.Buffer
+
+		rec := talker.NewRecorder(&stream)
+		ctx := rec.Install(talker.NewPower()).Context(context.Background(), "test")
+
+		talker.Event(ctx, "first", nil)
+		time.Sleep(100 * time.Millisecond)
+		talker.Event(ctx, "second", nil)
+
+		start := time.Now()
+
+		if err := talker.Replay(context.Background(), &stream, talker.NewPower(), talker.ReplayOptions{Speed: 2}); err != nil {
+			t.Fatal(err)
+		}
+
+		if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+			t.Fatalf("replay is not paced: %s", elapsed)
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package talker
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Resource describes the service that produces spans, events and metrics.
+// Hooks can read it from their context with the ResourceFromContext function,
+// so backends can tell services apart.
+type Resource struct {
+	ServiceName    string // ServiceName is the logical name of the service, e.g. "checkout".
+	ServiceVersion string // ServiceVersion is the version of the service, e.g. "1.2.3".
+	Environment    string // Environment is the deployment environment, e.g. "production".
+	InstanceID     string // InstanceID identifies the running instance, e.g. the pod name.
+	Attrs          Params // Attrs are additional resource attributes.
+}
+
+// Params returns the resource as params, using the OpenTelemetry semantic convention keys.
+// Empty fields are omitted.
+func (r Resource) Params() Params {
+	params := Params{}
+
+	for key, value := range r.Attrs {
+		params[key] = value
+	}
+
+	if r.ServiceName != "" {
+		params["service.name"] = r.ServiceName
+	}
+
+	if r.ServiceVersion != "" {
+		params["service.version"] = r.ServiceVersion
+	}
+
+	if r.Environment != "" {
+		params["deployment.environment"] = r.Environment
+	}
+
+	if r.InstanceID != "" {
+		params["service.instance.id"] = r.InstanceID
+	}
+
+	return params
+}
+
+// ResourceFromEnv creates a Resource from the OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES environment variables.
+// OTEL_RESOURCE_ATTRIBUTES is a comma separated list of percent encoded key=value pairs,
+// OTEL_SERVICE_NAME takes precedence over the service.name attribute.
+// Example:
+//
+//	// OTEL_SERVICE_NAME=checkout OTEL_RESOURCE_ATTRIBUTES=service.version=1.2.3,deployment.environment=production
+//	pwr := talker.NewPower(talker.WithResource(talker.ResourceFromEnv()))
+func ResourceFromEnv() Resource {
+	res := Resource{}
+
+	for _, pair := range strings.Split(os.Getenv("OTEL_RESOURCE_ATTRIBUTES"), ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+
+		key = strings.TrimSpace(key)
+
+		if unescaped, err := url.PathUnescape(strings.TrimSpace(value)); err == nil {
+			value = unescaped
+		}
+
+		switch key {
+		case "service.name":
+			res.ServiceName = value
+		case "service.version":
+			res.ServiceVersion = value
+		case "deployment.environment", "deployment.environment.name":
+			res.Environment = value
+		case "service.instance.id":
+			res.InstanceID = value
+		default:
+			if res.Attrs == nil {
+				res.Attrs = Params{}
+			}
+
+			res.Attrs[key] = value
+		}
+	}
+
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		res.ServiceName = name
+	}
+
+	return res
+}
+
+// ResourceFromContext returns the Resource of the Power in the context.
+func ResourceFromContext(ctx context.Context) Resource {
+	pwr, _ := ctx.Value(powerContextKey).(Power)
+
+	return pwr.resource
+}
+
+// PowerOption is an option for the NewPower function.
+type PowerOption func(Power) Power
+
+// WithResource sets the Resource of the Power.
+func WithResource(res Resource) PowerOption {
+	return func(c Power) Power {
+		c.resource = res
+
+		return c
+	}
+}
@@ -0,0 +1,52 @@
+package talker_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Arsfiqball/csverse/talker"
+)
+
+func TestResource(t *testing.T) {
+	t.Run("context name", func(t *testing.T) {
+		var res talker.Resource
+
+		pwr := talker.NewPower().WithEventHook(func(ctx context.Context, name string, attrs map[string]any) {
+			res = talker.ResourceFromContext(ctx)
+		})
+
+		talker.Event(pwr.Context(context.Background(), "checkout"), "done", nil)
+
+		if res.ServiceName != "checkout" {
+			t.Fatal("service name is not taken from the context name")
+		}
+	})
+
+	t.Run("option", func(t *testing.T) {
+		pwr := talker.NewPower(talker.WithResource(talker.Resource{ServiceName: "billing", Environment: "test"}))
+		res := talker.ResourceFromContext(pwr.Context(context.Background(), "checkout"))
+
+		if res.ServiceName != "billing" {
+			t.Fatal("service name is overridden by the context name")
+		}
+
+		if res.Params()["deployment.environment"] != "test" {
+			t.Fatal("environment is not in params")
+		}
+	})
+
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("OTEL_SERVICE_NAME", "checkout")
+		t.Setenv("OTEL_RESOURCE_ATTRIBUTES", "service.name=ignored, service.version=1.2.3,deployment.environment=prod,service.instance.id=pod%2D1,team=payments")
+
+		res := talker.ResourceFromEnv()
+
+		if res.ServiceName != "checkout" || res.ServiceVersion != "1.2.3" || res.Environment != "prod" || res.InstanceID != "pod-1" {
+			t.Fatalf("unexpected resource: %+v", res)
+		}
+
+		if res.Attrs["team"] != "payments" {
+			t.Fatal("additional attribute is not loaded")
+		}
+	})
+}
@@ -0,0 +1,193 @@
+package talker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// ErrRetryFailed is returned by RetryWith when callback failed more than once,
+// it wraps the errors of every attempt, so errors.Is works with any of them.
+var ErrRetryFailed = NewError("RETRY_FAILED", "retry failed")
+
+// Backoff is the strategy used to compute the delay between retry attempts.
+type Backoff int
+
+const (
+	ConstantBackoff           Backoff = iota // ConstantBackoff waits BaseDelay between attempts.
+	ExponentialBackoff                       // ExponentialBackoff multiplies the delay by Multiplier after every attempt.
+	DecorrelatedJitterBackoff                // DecorrelatedJitterBackoff waits a random delay between BaseDelay and three times the previous delay.
+)
+
+// RetryPolicy is the configuration for the RetryWith function.
+type RetryPolicy struct {
+	MaxAttempts int           // MaxAttempts is the maximum number of attempts, default is 3.
+	Backoff     Backoff       // Backoff is the strategy used to compute the delay, default is ConstantBackoff.
+	BaseDelay   time.Duration // BaseDelay is the first delay, default is 100ms.
+	MaxDelay    time.Duration // MaxDelay caps the delay, there is no cap when it is 0.
+	Multiplier  float64       // Multiplier is used by ExponentialBackoff, default is 2.
+	Jitter      bool          // Jitter randomizes the delay between 0 and the computed delay (full jitter).
+	MaxElapsed  time.Duration // MaxElapsed stops retrying when the next attempt would start after it, there is no limit when it is 0.
+
+	// RetryIf decides whether an error is retried, every error is retried when it is nil.
+	RetryIf func(err error) bool
+	// OnRetry is called before waiting for the next attempt.
+	OnRetry func(ctx context.Context, attempt int, err error, delay time.Duration)
+}
+
+// RetryOn returns a RetryPolicy.RetryIf function that only retries the given errors, compared with errors.Is.
+// Example:
+//
+//	policy := talker.RetryPolicy{RetryIf: talker.RetryOn(ErrTimeout, ErrUnavailable)}
+func RetryOn(targets ...error) func(err error) bool {
+	return func(err error) bool {
+		for _, target := range targets {
+			if errors.Is(err, target) {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+func (p RetryPolicy) sanitize() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 3
+	}
+
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = 100 * time.Millisecond
+	}
+
+	if p.Multiplier <= 0 {
+		p.Multiplier = 2
+	}
+
+	if p.RetryIf == nil {
+		p.RetryIf = func(err error) bool { return true }
+	}
+
+	return p
+}
+
+// maxRetryDelay is the largest delay, one below math.MaxInt64 so the jitter range does not overflow.
+const maxRetryDelay = time.Duration(math.MaxInt64 - 1)
+
+// delay returns the delay after the given attempt, prev is the previous delay.
+func (p RetryPolicy) delay(attempt int, prev time.Duration) time.Duration {
+	var d time.Duration
+
+	switch p.Backoff {
+	case ExponentialBackoff:
+		f := float64(p.BaseDelay) * math.Pow(p.Multiplier, float64(attempt-1))
+
+		d = maxRetryDelay
+		if f < float64(maxRetryDelay) { // false for +Inf and NaN too
+			d = time.Duration(f)
+		}
+	case DecorrelatedJitterBackoff:
+		upper := maxRetryDelay
+		if prev < maxRetryDelay/3 {
+			upper = prev * 3
+		}
+
+		if upper < p.BaseDelay {
+			upper = p.BaseDelay
+		}
+
+		d = p.BaseDelay + time.Duration(rand.Int63n(int64(upper-p.BaseDelay)+1))
+	default:
+		d = p.BaseDelay
+	}
+
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+
+	if d > maxRetryDelay {
+		d = maxRetryDelay
+	}
+
+	if p.Jitter && d > 0 {
+		d = time.Duration(rand.Int63n(int64(d) + 1))
+	}
+
+	return d
+}
+
+// RetryWith runs callback until it succeeds, following the policy.
+// Waiting between attempts stops as soon as the context is done.
+// When more than one attempt failed, ErrRetryFailed wrapping every error is returned.
+// Example:
+//
+//	err := talker.RetryWith(
+//		func(ctx context.Context) error {
+//			// ... do something
+//			return nil
+//		},
+//		talker.RetryPolicy{
+//			MaxAttempts: 5,
+//			Backoff:     talker.ExponentialBackoff,
+//			BaseDelay:   100 * time.Millisecond,
+//			MaxDelay:    5 * time.Second,
+//			Jitter:      true,
+//			MaxElapsed:  30 * time.Second,
+//		},
+//	)(context.Background())
+func RetryWith(callback Callback, policy RetryPolicy) Callback {
+	policy = policy.sanitize()
+
+	return func(ctx context.Context) error {
+		ctx, end := combinatorSpan(ctx, "talker.retry", Params{"retries": policy.MaxAttempts})
+		defer end()
+
+		start := time.Now()
+		errs := []error{}
+
+		var delay time.Duration
+
+		for attempt := 1; ; attempt++ {
+			attemptCtx, endAttempt := combinatorSpan(ctx, "talker.retry.attempt", Params{"attempt": attempt})
+			err := callback(attemptCtx)
+			endAttempt()
+
+			if err == nil {
+				return nil
+			}
+
+			errs = append(errs, err)
+
+			if attempt >= policy.MaxAttempts || !policy.RetryIf(err) {
+				break
+			}
+
+			delay = policy.delay(attempt, delay)
+
+			if policy.MaxElapsed > 0 && delay > policy.MaxElapsed-time.Since(start) { // does not overflow with a large delay
+				break
+			}
+
+			if policy.OnRetry != nil {
+				policy.OnRetry(ctx, attempt, err, delay)
+			}
+
+			if err := sleepContext(ctx, delay); err != nil {
+				errs = append(errs, err)
+				break
+			}
+		}
+
+		if len(errs) == 1 {
+			return errs[0]
+		}
+
+		return ErrRetryFailed.
+			Wrap(errors.Join(errs...)).
+			WithInfo(fmt.Sprintf("retry failed after %d errors: %s", len(errs), errs[len(errs)-1])).
+			WithData(len(errs))
+	}
+}
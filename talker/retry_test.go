@@ -0,0 +1,235 @@
+package talker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Arsfiqball/csverse/talker"
+)
+
+func TestRetryWith(t *testing.T) {
+	errTemporary := talker.NewError("TEMPORARY", "temporary failure")
+	errPermanent := talker.NewError("PERMANENT", "permanent failure")
+
+	t.Run("success after failures", func(t *testing.T) {
+		attempts := 0
+
+		err := talker.RetryWith(func(ctx context.Context) error {
+			attempts++
+
+			if attempts < 3 {
+				return errTemporary
+			}
+
+			return nil
+		}, talker.RetryPolicy{MaxAttempts: 5, BaseDelay: time.Millisecond})(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if attempts != 3 {
+			t.Fatalf("unexpected attempts: %d", attempts)
+		}
+	})
+
+	t.Run("aggregated error", func(t *testing.T) {
+		attempts := 0
+
+		err := talker.RetryWith(func(ctx context.Context) error {
+			attempts++
+
+			if attempts == 1 {
+				return errPermanent.WithInfo("first")
+			}
+
+			return errTemporary
+		}, talker.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})(context.Background())
+
+		if attempts != 3 {
+			t.Fatalf("unexpected attempts: %d", attempts)
+		}
+
+		if !errors.Is(err, talker.ErrRetryFailed) || !errors.Is(err, errPermanent) || !errors.Is(err, errTemporary) {
+			t.Fatalf("attempts are not aggregated: %v", err)
+		}
+
+		var retryErr talker.Error
+		if !errors.As(err, &retryErr) || retryErr.Data() != 3 {
+			t.Fatal("number of attempts is not set as data")
+		}
+	})
+
+	t.Run("retry predicate", func(t *testing.T) {
+		attempts := 0
+
+		err := talker.RetryWith(func(ctx context.Context) error {
+			attempts++
+
+			if attempts == 1 {
+				return errTemporary
+			}
+
+			return errPermanent
+		}, talker.RetryPolicy{
+			MaxAttempts: 5,
+			BaseDelay:   time.Millisecond,
+			RetryIf:     talker.RetryOn(errTemporary),
+		})(context.Background())
+
+		if attempts != 2 {
+			t.Fatalf("unexpected attempts: %d", attempts)
+		}
+
+		if !errors.Is(err, errPermanent) {
+			t.Fatal("last error is not returned")
+		}
+	})
+
+	t.Run("single attempt returns error as is", func(t *testing.T) {
+		err := talker.RetryWith(func(ctx context.Context) error {
+			return errPermanent
+		}, talker.RetryPolicy{RetryIf: talker.RetryOn(errTemporary)})(context.Background())
+
+		if errors.Is(err, talker.ErrRetryFailed) || !errors.Is(err, errPermanent) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("exponential backoff with max delay", func(t *testing.T) {
+		delays := []time.Duration{}
+
+		_ = talker.RetryWith(func(ctx context.Context) error {
+			return errTemporary
+		}, talker.RetryPolicy{
+			MaxAttempts: 5,
+			Backoff:     talker.ExponentialBackoff,
+			BaseDelay:   time.Millisecond,
+			MaxDelay:    4 * time.Millisecond,
+			OnRetry: func(ctx context.Context, attempt int, err error, delay time.Duration) {
+				delays = append(delays, delay)
+			},
+		})(context.Background())
+
+		expected := []time.Duration{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond, 4 * time.Millisecond}
+
+		if len(delays) != len(expected) {
+			t.Fatalf("unexpected delays: %v", delays)
+		}
+
+		for i := range expected {
+			if delays[i] != expected[i] {
+				t.Fatalf("unexpected delays: %v", delays)
+			}
+		}
+	})
+
+	t.Run("decorrelated jitter", func(t *testing.T) {
+		delays := []time.Duration{}
+
+		_ = talker.RetryWith(func(ctx context.Context) error {
+			return errTemporary
+		}, talker.RetryPolicy{
+			MaxAttempts: 6,
+			Backoff:     talker.DecorrelatedJitterBackoff,
+			BaseDelay:   time.Millisecond,
+			MaxDelay:    5 * time.Millisecond,
+			OnRetry: func(ctx context.Context, attempt int, err error, delay time.Duration) {
+				delays = append(delays, delay)
+			},
+		})(context.Background())
+
+		for _, delay := range delays {
+			if delay < time.Millisecond || delay > 5*time.Millisecond {
+				t.Fatalf("delay out of range: %v", delays)
+			}
+		}
+	})
+
+	t.Run("max elapsed", func(t *testing.T) {
+		attempts := 0
+
+		_ = talker.RetryWith(func(ctx context.Context) error {
+			attempts++
+			return errTemporary
+		}, talker.RetryPolicy{
+			MaxAttempts: 100,
+			BaseDelay:   20 * time.Millisecond,
+			MaxElapsed:  50 * time.Millisecond,
+		})(context.Background())
+
+		if attempts != 3 {
+			t.Fatalf("unexpected attempts: %d", attempts)
+		}
+	})
+
+	t.Run("overflowing backoff", func(t *testing.T) {
+		for _, jitter := range []bool{false, true} {
+			attempts := 0
+			start := time.Now()
+
+			err := talker.RetryWith(func(ctx context.Context) error {
+				attempts++
+				return errTemporary
+			}, talker.RetryPolicy{
+				MaxAttempts: 100,
+				Backoff:     talker.ExponentialBackoff,
+				BaseDelay:   time.Millisecond,
+				Multiplier:  1e18, // overflows at the second retry, without MaxDelay
+				Jitter:      jitter,
+				MaxElapsed:  time.Second,
+			})(context.Background())
+
+			if !errors.Is(err, talker.ErrRetryFailed) || attempts != 2 || time.Since(start) > time.Second {
+				t.Fatalf("unexpected result with jitter %v: %v after %d attempts", jitter, err, attempts)
+			}
+		}
+	})
+
+	t.Run("cancelled while waiting", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			cancel()
+		}()
+
+		start := time.Now()
+
+		err := talker.RetryWith(func(ctx context.Context) error {
+			return errTemporary
+		}, talker.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Hour})(ctx)
+
+		if time.Since(start) > time.Second {
+			t.Fatal("waiting is not stopped by the context")
+		}
+
+		if !errors.Is(err, context.Canceled) || !errors.Is(err, errTemporary) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestRetryCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+
+	err := talker.Retry(func(ctx context.Context) error {
+		return errors.New("failed")
+	}, 3, time.Hour)(ctx)
+
+	if time.Since(start) > time.Second {
+		t.Fatal("waiting is not stopped by the context")
+	}
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package talker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// ErrSagaFailed is the error returned when a step of a Saga fails,
+// the data of the error is a SagaFailure.
+var ErrSagaFailed = NewError("SAGA_FAILED", "saga failed")
+
+// ErrSagaCompensation wraps the error of a compensation that still failed after its retries.
+var ErrSagaCompensation = NewError("SAGA_COMPENSATION", "saga compensation failed")
+
+// SagaStep is a step of a Saga.
+type SagaStep struct {
+	Name       string   // Name is used in errors and spans, default is the index of the step.
+	Action     Callback // Action is the work of the step.
+	Compensate Callback // Compensate undoes Action, it is optional.
+}
+
+// SagaOptions is the configuration for the Saga function.
+type SagaOptions struct {
+	// CompensationRetry is the retry policy of every compensation, check out RetryPolicy for the defaults.
+	// Use MaxAttempts: 1 to run compensations only once.
+	CompensationRetry RetryPolicy
+
+	// CompensationTimeout bounds every compensation with its retries, default is 30 seconds.
+	// A compensation that is still running is cancelled, and counted as failed.
+	CompensationTimeout time.Duration
+}
+
+// SagaFailure is the data of ErrSagaFailed.
+type SagaFailure struct {
+	Step          string  // Step is the name of the failed step.
+	Index         int     // Index is the position of the failed step.
+	Cause         error   // Cause is the error returned by the failed step.
+	Compensations []error // Compensations are the compensation errors (ErrSagaCompensation), in the order they ran.
+}
+
+// Saga runs the steps sequentially. When a step fails, the compensations of the completed steps
+// run in reverse order, and ErrSagaFailed is returned with the original cause and the compensation failures.
+// The failed step itself is not compensated. Compensations keep running when the context is cancelled,
+// since they are needed the most when the saga was interrupted, until SagaOptions.CompensationTimeout.
+// Unlike Atomic, the error of the failed step is never lost.
+// Example:
+//
+//	err := talker.Saga(
+//		talker.SagaOptions{CompensationRetry: talker.RetryPolicy{MaxAttempts: 5, Backoff: talker.ExponentialBackoff}},
+//		talker.SagaStep{
+//			Name:       "reserve_stock",
+//			Action:     reserveStock,
+//			Compensate: releaseStock,
+//		},
+//		talker.SagaStep{
+//			Name:       "charge_card",
+//			Action:     chargeCard,
+//			Compensate: refundCard,
+//		},
+//		talker.SagaStep{
+//			Name:   "send_email",
+//			Action: sendEmail,
+//		},
+//	)(ctx)
+func Saga(opts SagaOptions, steps ...SagaStep) Callback {
+	return func(ctx context.Context) error {
+		ctx, end := combinatorSpan(ctx, "talker.saga", Params{"steps": len(steps)})
+		defer end()
+
+		for i, step := range steps {
+			stepCtx, endStep := combinatorSpan(ctx, "talker.saga.step", Params{"index": i, "name": sagaStepName(step, i)})
+			err := step.Action(stepCtx)
+			endStep()
+
+			if err != nil {
+				return compensateSaga(ctx, opts, steps, i, err)
+			}
+		}
+
+		return nil
+	}
+}
+
+func sagaStepName(step SagaStep, index int) string {
+	if step.Name != "" {
+		return step.Name
+	}
+
+	return strconv.Itoa(index)
+}
+
+func compensateSaga(ctx context.Context, opts SagaOptions, steps []SagaStep, failed int, cause error) error {
+	ctx = context.WithoutCancel(ctx)
+
+	timeout := opts.CompensationTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	failure := SagaFailure{Step: sagaStepName(steps[failed], failed), Index: failed, Cause: cause}
+
+	for i := failed - 1; i >= 0; i-- {
+		if steps[i].Compensate == nil {
+			continue
+		}
+
+		name := sagaStepName(steps[i], i)
+
+		compensateCtx, cancel := context.WithTimeout(ctx, timeout)
+		compensateCtx, endCompensate := combinatorSpan(compensateCtx, "talker.saga.compensate", Params{"index": i, "name": name})
+		err := RetryWith(steps[i].Compensate, opts.CompensationRetry)(compensateCtx)
+		endCompensate()
+		cancel()
+
+		if err != nil {
+			failure.Compensations = append(failure.Compensations, ErrSagaCompensation.
+				Wrap(err).
+				WithInfo(fmt.Sprintf("compensation of saga step %s failed: %s", name, err)))
+		}
+	}
+
+	info := fmt.Sprintf("saga step %s failed: %s", failure.Step, cause)
+	if len(failure.Compensations) > 0 {
+		info += fmt.Sprintf(" (%d compensations failed)", len(failure.Compensations))
+	}
+
+	return ErrSagaFailed.
+		Wrap(errors.Join(append([]error{cause}, failure.Compensations...)...)).
+		WithInfo(info).
+		WithData(failure)
+}
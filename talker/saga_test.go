@@ -0,0 +1,192 @@
+package talker_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Arsfiqball/csverse/talker"
+)
+
+func TestSaga(t *testing.T) {
+	errFailed := talker.NewError("FAILED", "failed")
+
+	t.Run("success", func(t *testing.T) {
+		log := []string{}
+		step := func(name string) talker.SagaStep {
+			return talker.SagaStep{
+				Name:       name,
+				Action:     func(ctx context.Context) error { log = append(log, name); return nil },
+				Compensate: func(ctx context.Context) error { log = append(log, "undo "+name); return nil },
+			}
+		}
+
+		if err := talker.Saga(talker.SagaOptions{}, step("a"), step("b"))(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.Join(log, ",") != "a,b" {
+			t.Fatalf("unexpected log: %v", log)
+		}
+	})
+
+	t.Run("compensations in reverse order", func(t *testing.T) {
+		log := []string{}
+		step := func(name string, err error) talker.SagaStep {
+			return talker.SagaStep{
+				Name: name,
+				Action: func(ctx context.Context) error {
+					log = append(log, name)
+					return err
+				},
+				Compensate: func(ctx context.Context) error {
+					log = append(log, "undo "+name)
+					return nil
+				},
+			}
+		}
+
+		err := talker.Saga(
+			talker.SagaOptions{},
+			step("a", nil),
+			talker.SagaStep{Name: "no_compensation", Action: func(ctx context.Context) error { return nil }},
+			step("b", nil),
+			step("c", errFailed),
+			step("d", nil),
+		)(context.Background())
+
+		if strings.Join(log, ",") != "a,b,c,undo b,undo a" {
+			t.Fatalf("unexpected log: %v", log)
+		}
+
+		if !errors.Is(err, talker.ErrSagaFailed) || !errors.Is(err, errFailed) {
+			t.Fatalf("cause is lost: %v", err)
+		}
+
+		var sagaErr talker.Error
+		if !errors.As(err, &sagaErr) {
+			t.Fatal("error is not a talker.Error")
+		}
+
+		failure := sagaErr.Data().(talker.SagaFailure)
+		if failure.Step != "c" || failure.Index != 3 || !errors.Is(failure.Cause, errFailed) || len(failure.Compensations) != 0 {
+			t.Fatalf("unexpected failure: %+v", failure)
+		}
+	})
+
+	t.Run("compensation retry and failure", func(t *testing.T) {
+		errUndo := errors.New("undo failed")
+		attempts := map[string]int{}
+
+		err := talker.Saga(
+			talker.SagaOptions{CompensationRetry: talker.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}},
+			talker.SagaStep{
+				Name:   "flaky",
+				Action: func(ctx context.Context) error { return nil },
+				Compensate: func(ctx context.Context) error {
+					attempts["flaky"]++
+					if attempts["flaky"] < 2 {
+						return errUndo
+					}
+					return nil
+				},
+			},
+			talker.SagaStep{
+				Name:   "broken",
+				Action: func(ctx context.Context) error { return nil },
+				Compensate: func(ctx context.Context) error {
+					attempts["broken"]++
+					return errUndo
+				},
+			},
+			talker.SagaStep{
+				Name:   "fail",
+				Action: func(ctx context.Context) error { return errFailed },
+			},
+		)(context.Background())
+
+		if attempts["flaky"] != 2 || attempts["broken"] != 3 {
+			t.Fatalf("unexpected attempts: %v", attempts)
+		}
+
+		if !errors.Is(err, errFailed) || !errors.Is(err, talker.ErrSagaCompensation) || !errors.Is(err, errUndo) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var sagaErr talker.Error
+		errors.As(err, &sagaErr)
+
+		failure := sagaErr.Data().(talker.SagaFailure)
+		if len(failure.Compensations) != 1 || !strings.Contains(failure.Compensations[0].Error(), "broken") {
+			t.Fatalf("unexpected compensations: %v", failure.Compensations)
+		}
+	})
+
+	t.Run("compensations run after cancellation", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		compensated := false
+
+		err := talker.Saga(
+			talker.SagaOptions{},
+			talker.SagaStep{
+				Action: func(ctx context.Context) error { return nil },
+				Compensate: func(ctx context.Context) error {
+					compensated = ctx.Err() == nil
+					return nil
+				},
+			},
+			talker.SagaStep{
+				Action: func(ctx context.Context) error {
+					cancel()
+					return ctx.Err()
+				},
+			},
+		)(ctx)
+
+		if !compensated {
+			t.Fatal("compensation is not run with a live context")
+		}
+
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("compensation timeout", func(t *testing.T) {
+		compensated := false
+
+		err := talker.Saga(
+			talker.SagaOptions{CompensationRetry: talker.RetryPolicy{MaxAttempts: 1}, CompensationTimeout: 20 * time.Millisecond},
+			talker.SagaStep{
+				Action:     func(ctx context.Context) error { return nil },
+				Compensate: func(ctx context.Context) error { compensated = true; return nil },
+			},
+			talker.SagaStep{
+				Action: func(ctx context.Context) error { return nil },
+				Compensate: func(ctx context.Context) error {
+					<-ctx.Done() // hung compensation
+					return ctx.Err()
+				},
+			},
+			talker.SagaStep{
+				Action: func(ctx context.Context) error { return errors.New("failed") },
+			},
+		)(context.Background())
+
+		var sagaErr talker.Error
+		if !errors.As(err, &sagaErr) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		failure := sagaErr.Data().(talker.SagaFailure)
+		if len(failure.Compensations) != 1 || !errors.Is(failure.Compensations[0], context.DeadlineExceeded) {
+			t.Fatalf("unexpected compensations: %v", failure.Compensations)
+		}
+
+		if !compensated {
+			t.Fatal("compensation after the hung one is not run")
+		}
+	})
+}
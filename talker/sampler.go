@@ -0,0 +1,127 @@
+package talker
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Sampler decides whether a span or an event with the given name is recorded.
+// Samplers are configured on the Power with the WithSpanSampler and WithEventSampler methods.
+type Sampler func(ctx context.Context, name string) bool
+
+const samplingContextKey = PowerContextKey("sampling_context")
+
+// Sampled reports whether the span in the context is recorded.
+// It returns true when no sampling decision has been made yet.
+func Sampled(ctx context.Context) bool {
+	sampled, ok := ctx.Value(samplingContextKey).(bool)
+
+	return !ok || sampled
+}
+
+func withSamplingDecision(ctx context.Context, sampled bool) context.Context {
+	if current, ok := ctx.Value(samplingContextKey).(bool); ok && current == sampled {
+		return ctx
+	}
+
+	return context.WithValue(ctx, samplingContextKey, sampled)
+}
+
+// AlwaysSample returns a Sampler that records everything.
+func AlwaysSample() Sampler {
+	return func(ctx context.Context, name string) bool {
+		return true
+	}
+}
+
+// NeverSample returns a Sampler that drops everything.
+func NeverSample() Sampler {
+	return func(ctx context.Context, name string) bool {
+		return false
+	}
+}
+
+// RatioSampler returns a Sampler that records the given fraction of spans or events.
+// A ratio of 0 or lower drops everything, a ratio of 1 or higher records everything.
+func RatioSampler(ratio float64) Sampler {
+	if ratio <= 0 {
+		return NeverSample()
+	}
+
+	if ratio >= 1 {
+		return AlwaysSample()
+	}
+
+	return func(ctx context.Context, name string) bool {
+		return rand.Float64() < ratio
+	}
+}
+
+// RateLimitSampler returns a Sampler that records at most perSecond spans or events per second
+// for every name, bursts of up to one second worth of spans or events are allowed.
+func RateLimitSampler(perSecond float64) Sampler {
+	if perSecond <= 0 {
+		return NeverSample()
+	}
+
+	type bucket struct {
+		tokens float64
+		last   time.Time
+	}
+
+	var mu sync.Mutex
+
+	burst := perSecond
+	if burst < 1 {
+		burst = 1
+	}
+
+	buckets := map[string]*bucket{}
+
+	return func(ctx context.Context, name string) bool {
+		now := time.Now()
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		b, ok := buckets[name]
+		if !ok {
+			b = &bucket{tokens: burst, last: now}
+			buckets[name] = b
+		}
+
+		b.tokens += now.Sub(b.last).Seconds() * perSecond
+		b.last = now
+
+		if b.tokens > burst {
+			b.tokens = burst
+		}
+
+		if b.tokens < 1 {
+			return false
+		}
+
+		b.tokens--
+
+		return true
+	}
+}
+
+// ParentBasedSampler returns a Sampler that follows the decision of the parent span,
+// the root sampler is used when there is no parent decision in the context.
+// Example:
+//
+//	pwr := talker.NewPower().
+//		WithSpanSampler(talker.ParentBasedSampler(talker.RatioSampler(0.1))).
+//		WithEventSampler(talker.ParentBasedSampler(talker.AlwaysSample()))
+func ParentBasedSampler(root Sampler) Sampler {
+	return func(ctx context.Context, name string) bool {
+		if sampled, ok := ctx.Value(samplingContextKey).(bool); ok {
+			return sampled
+		}
+
+		return root(ctx, name)
+	}
+}
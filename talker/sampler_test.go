@@ -0,0 +1,107 @@
+package talker_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Arsfiqball/csverse/talker"
+)
+
+func TestSampler(t *testing.T) {
+	counter := func(spans *[]string) talker.SpanHook {
+		return func(ctx context.Context, name string, attrs map[string]any) (context.Context, func()) {
+			*spans = append(*spans, name)
+			return ctx, func() {}
+		}
+	}
+
+	t.Run("never", func(t *testing.T) {
+		var spans []string
+
+		pwr := talker.NewPower().WithSpanHook(counter(&spans)).WithSpanSampler(talker.NeverSample())
+		ctx, end := talker.Span(pwr.Context(context.Background(), "test"), "root", nil)
+		end()
+
+		if len(spans) != 0 {
+			t.Fatal("dropped span calls hook")
+		}
+
+		if talker.Sampled(ctx) {
+			t.Fatal("decision is not stored in the context")
+		}
+	})
+
+	t.Run("parent based", func(t *testing.T) {
+		var spans []string
+
+		root := true
+		pwr := talker.NewPower().
+			WithSpanHook(counter(&spans)).
+			WithSpanSampler(talker.ParentBasedSampler(func(ctx context.Context, name string) bool { return root }))
+
+		ctx, end := talker.Span(pwr.Context(context.Background(), "test"), "root", nil)
+		root = false // children must follow the parent, not the root sampler
+		_, endChild := talker.Span(ctx, "child", nil)
+		endChild()
+		end()
+
+		if len(spans) != 2 {
+			t.Fatalf("expected 2 spans, got %v", spans)
+		}
+
+		spans = nil
+
+		ctx, end = talker.Span(pwr.Context(context.Background(), "test"), "root", nil)
+		root = true
+		_, endChild = talker.Span(ctx, "child", nil)
+		endChild()
+		end()
+
+		if len(spans) != 0 {
+			t.Fatalf("expected 0 spans, got %v", spans)
+		}
+	})
+
+	t.Run("events", func(t *testing.T) {
+		count := 0
+
+		pwr := talker.NewPower().
+			WithSpanSampler(talker.NeverSample()).
+			WithEventSampler(talker.ParentBasedSampler(talker.AlwaysSample())).
+			WithEventHook(func(ctx context.Context, name string, attrs map[string]any) { count++ })
+
+		ctx := pwr.Context(context.Background(), "test")
+		talker.Event(ctx, "outside", nil)
+
+		ctx, end := talker.Span(ctx, "root", nil)
+		talker.Event(ctx, "inside", nil)
+		end()
+
+		if count != 1 {
+			t.Fatalf("expected 1 event, got %d", count)
+		}
+	})
+
+	t.Run("rate limit", func(t *testing.T) {
+		sampler := talker.RateLimitSampler(2)
+		ctx := context.Background()
+
+		if !sampler(ctx, "a") || !sampler(ctx, "a") || sampler(ctx, "a") {
+			t.Fatal("rate limit is not applied")
+		}
+
+		if !sampler(ctx, "b") {
+			t.Fatal("rate limit is not per name")
+		}
+	})
+
+	t.Run("ratio", func(t *testing.T) {
+		if talker.RatioSampler(0)(context.Background(), "a") {
+			t.Fatal("ratio 0 records")
+		}
+
+		if !talker.RatioSampler(1)(context.Background(), "a") {
+			t.Fatal("ratio 1 drops")
+		}
+	})
+}
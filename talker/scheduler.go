@@ -0,0 +1,445 @@
+package talker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrSchedulerJob is the error returned when a job can not be added to a Scheduler.
+var ErrSchedulerJob = NewError("SCHEDULER_JOB", "scheduler job can not be added")
+
+// Clock tells the time and waits, it is used by the Scheduler so it can be tested with a FakeClock.
+type Clock interface {
+	Now() time.Time
+	Sleep(ctx context.Context, d time.Duration) error // Sleep returns the context error as soon as the context is done.
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) Sleep(ctx context.Context, d time.Duration) error { return sleepContext(ctx, d) }
+
+// SystemClock is the Clock of the system.
+var SystemClock Clock = systemClock{}
+
+// FakeClock is a Clock that only moves with Advance, for tests.
+// This struct must be created with the NewFakeClock function.
+type FakeClock struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	now      time.Time
+	sleepers []*fakeSleeper
+}
+
+type fakeSleeper struct {
+	until time.Time
+	done  chan struct{}
+}
+
+// NewFakeClock creates a new FakeClock set to now.
+// Example:
+//
+//	clock := talker.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+//	scheduler := talker.NewScheduler(clock)
+//	// ... add jobs and start the scheduler
+//
+//	clock.BlockUntil(1)      // wait for the job to sleep
+//	clock.Advance(time.Hour) // run the job
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.cond = sync.NewCond(&c.mu)
+
+	return c
+}
+
+// Now returns the time of the clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// Sleep waits until the clock is advanced by d.
+func (c *FakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	c.mu.Lock()
+	s := &fakeSleeper{until: c.now.Add(d), done: make(chan struct{})}
+	c.sleepers = append(c.sleepers, s)
+	c.cond.Broadcast()
+	c.mu.Unlock()
+
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		c.mu.Lock()
+		c.remove(s)
+		c.mu.Unlock()
+
+		return ctx.Err()
+	}
+}
+
+// Advance moves the clock forward and wakes the sleepers that are due.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	for _, s := range append([]*fakeSleeper{}, c.sleepers...) {
+		if !s.until.After(c.now) {
+			close(s.done)
+			c.remove(s)
+		}
+	}
+}
+
+// BlockUntil waits until at least n goroutines are sleeping.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for len(c.sleepers) < n {
+		c.cond.Wait()
+	}
+}
+
+func (c *FakeClock) remove(s *fakeSleeper) {
+	for i, sleeper := range c.sleepers {
+		if sleeper == s {
+			c.sleepers = append(c.sleepers[:i], c.sleepers[i+1:]...)
+			return
+		}
+	}
+}
+
+// OverlapPolicy decides what happens when a job is due while its previous run is still running.
+type OverlapPolicy int
+
+const (
+	SkipOverlap  OverlapPolicy = iota // SkipOverlap does not run the job, the run is counted as skipped.
+	AllowOverlap                      // AllowOverlap runs the job next to the previous run.
+)
+
+// MissedPolicy decides what happens when the scheduler wakes up after more than one run time passed,
+// e.g. when the machine was suspended or the clock jumped.
+type MissedPolicy int
+
+const (
+	SkipMissed    MissedPolicy = iota // SkipMissed does not run the missed runs, the job waits for its next run time.
+	RunOnceMissed                     // RunOnceMissed runs the job once for all the missed runs.
+)
+
+// JobOptions is the configuration of a job added to a Scheduler.
+type JobOptions struct {
+	Location *time.Location // Location is the time zone used to compute the run times, default is time.Local.
+	Jitter   time.Duration  // Jitter delays every run by a random duration up to Jitter, so many instances do not run at once.
+	Overlap  OverlapPolicy  // Overlap decides what happens when the previous run is still running, default is SkipOverlap.
+	Missed   MissedPolicy   // Missed decides what happens with missed runs, default is SkipMissed.
+}
+
+// JobStatus is a snapshot of a scheduled job.
+type JobStatus struct {
+	Name         string        `json:"name"`
+	Schedule     string        `json:"schedule"`
+	Next         time.Time     `json:"next"`          // Next is the next run time, without jitter.
+	LastStart    time.Time     `json:"last_start"`    // LastStart is when the last run started.
+	LastDuration time.Duration `json:"last_duration"` // LastDuration is the duration of the last completed run.
+	LastError    string        `json:"last_error,omitempty"`
+	Running      int           `json:"running"` // Running is the number of runs in progress.
+	Runs         int           `json:"runs"`    // Runs is the number of started runs.
+	Skipped      int           `json:"skipped"` // Skipped is the number of runs skipped by the overlap policy.
+	Missed       int           `json:"missed"`  // Missed is the number of runs missed, check out MissedPolicy.
+}
+
+type scheduledJob struct {
+	name     string
+	schedule Schedule
+	callback Callback
+	opts     JobOptions
+
+	mu     sync.Mutex
+	status JobStatus
+}
+
+// Scheduler runs jobs with cron expressions or fixed intervals.
+// Every run is traced with a "scheduler.run" span, and failures are recorded with SpanHandle.RecordError,
+// using the Power given to WithPower or else the Power in the context given to Start.
+// Its Start and Stop methods can be used as (part of) Process.Start and Process.Stop,
+// and it can be served by the monitor server with Process.Scheduler.
+// This struct must be created with the NewScheduler function.
+type Scheduler struct {
+	clock Clock
+	pwr   *Power
+
+	mu      sync.Mutex
+	jobs    []*scheduledJob
+	started bool
+
+	loops      sync.WaitGroup
+	runs       sync.WaitGroup
+	stopLoops  context.CancelFunc
+	cancelRuns context.CancelFunc
+}
+
+// NewScheduler creates a new Scheduler with the given clock, default is SystemClock.
+// Example:
+//
+//	scheduler := talker.NewScheduler(nil).WithPower(pwr)
+//
+//	err := scheduler.Cron("cleanup", "0 3 * * *", cleanup, talker.JobOptions{Location: jakarta})
+//	if err != nil {
+//		return err
+//	}
+//
+//	scheduler.Add("refresh", talker.Every(5*time.Minute), refresh, talker.JobOptions{Jitter: 30 * time.Second})
+//
+//	proc := talker.Process{
+//		Start:     talker.Sequential(startServer, scheduler.Start),
+//		Stop:      talker.Sequential(scheduler.Stop, stopServer),
+//		Scheduler: scheduler, // Optional, served at /scheduler
+//	}
+func NewScheduler(clock Clock) *Scheduler {
+	if clock == nil {
+		clock = SystemClock
+	}
+
+	return &Scheduler{clock: clock}
+}
+
+// WithPower adds the Power to the context of every run, so the runs are traced
+// even when the context given to Start has no Power, e.g. when it is started by Serve.
+func (s *Scheduler) WithPower(pwr Power) *Scheduler {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pwr = &pwr
+
+	return s
+}
+
+// Cron adds a job that runs with a cron expression, check out ParseCron.
+func (s *Scheduler) Cron(name string, expr string, callback Callback, opts JobOptions) error {
+	schedule, err := ParseCron(expr)
+	if err != nil {
+		return err
+	}
+
+	return s.Add(name, schedule, callback, opts)
+}
+
+// Add adds a job that runs with the given schedule. Jobs must be added before Start, with unique names.
+func (s *Scheduler) Add(name string, schedule Schedule, callback Callback, opts JobOptions) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return ErrSchedulerJob.WithInfo(fmt.Sprintf("scheduler job %s is added after the scheduler started", name))
+	}
+
+	for _, job := range s.jobs {
+		if job.name == name {
+			return ErrSchedulerJob.WithInfo(fmt.Sprintf("scheduler job %s is added twice", name))
+		}
+	}
+
+	if opts.Location == nil {
+		opts.Location = time.Local
+	}
+
+	s.jobs = append(s.jobs, &scheduledJob{
+		name:     name,
+		schedule: schedule,
+		callback: callback,
+		opts:     opts,
+		status:   JobStatus{Name: name, Schedule: schedule.String()},
+	})
+
+	return nil
+}
+
+// Start starts the jobs and returns, the jobs keep running until Stop is called or the context is done.
+func (s *Scheduler) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return nil
+	}
+
+	s.started = true
+
+	var loopCtx, runCtx context.Context
+
+	loopCtx, s.stopLoops = context.WithCancel(ctx)
+	runCtx, s.cancelRuns = context.WithCancel(context.WithoutCancel(ctx)) // runs are stopped by Stop only
+
+	if s.pwr != nil {
+		runCtx = s.pwr.Context(runCtx, "")
+	}
+
+	for _, job := range s.jobs {
+		s.loops.Add(1)
+
+		go func(job *scheduledJob) {
+			defer s.loops.Done()
+			s.loop(loopCtx, runCtx, job)
+		}(job)
+	}
+
+	return nil
+}
+
+// Stop stops scheduling new runs and waits for the running ones.
+// When the context is done first, the context of the running jobs is cancelled and the context error is returned.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	started := s.started
+	s.mu.Unlock()
+
+	if !started {
+		return nil
+	}
+
+	s.stopLoops()
+	s.loops.Wait()
+
+	done := make(chan struct{})
+
+	go func() {
+		s.runs.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.cancelRuns()
+		return nil
+	case <-ctx.Done():
+		s.cancelRuns()
+		return ctx.Err()
+	}
+}
+
+// Status returns the status of every job, in the order they were added.
+func (s *Scheduler) Status() []JobStatus {
+	s.mu.Lock()
+	jobs := append([]*scheduledJob{}, s.jobs...)
+	s.mu.Unlock()
+
+	statuses := make([]JobStatus, 0, len(jobs))
+
+	for _, job := range jobs {
+		job.mu.Lock()
+		statuses = append(statuses, job.status)
+		job.mu.Unlock()
+	}
+
+	return statuses
+}
+
+// ServeHTTP writes the status of every job as JSON.
+func (s *Scheduler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(s.Status())
+}
+
+func (s *Scheduler) loop(loopCtx context.Context, runCtx context.Context, job *scheduledJob) {
+	now := s.clock.Now().In(job.opts.Location)
+	next := job.nextAfter(now)
+
+	for !next.IsZero() {
+		job.mu.Lock()
+		job.status.Next = next
+		job.mu.Unlock()
+
+		var jitter time.Duration
+		if job.opts.Jitter > 0 {
+			jitter = time.Duration(rand.Int63n(int64(job.opts.Jitter)) + 1)
+		}
+
+		if err := s.clock.Sleep(loopCtx, next.Sub(s.clock.Now())+jitter); err != nil {
+			return
+		}
+
+		now = s.clock.Now().In(job.opts.Location)
+		missed := !job.schedule.Next(next).After(now.Add(-jitter)) // the run after next is due too
+
+		if !missed || job.opts.Missed == RunOnceMissed {
+			s.run(runCtx, job, next)
+		}
+
+		if missed {
+			job.mu.Lock()
+			job.status.Missed++
+			job.mu.Unlock()
+		}
+
+		next = job.nextAfter(now)
+	}
+
+	job.mu.Lock()
+	job.status.Next = time.Time{}
+	job.mu.Unlock()
+}
+
+// nextAfter returns the next run time after now. A schedule that does not move forward stops the job,
+// otherwise the loop would spin on the same run time.
+func (job *scheduledJob) nextAfter(now time.Time) time.Time {
+	next := job.schedule.Next(now)
+	if next.IsZero() || next.After(now) {
+		return next
+	}
+
+	job.mu.Lock()
+	job.status.LastError = fmt.Sprintf("schedule %s returned %s, which is not after %s", job.schedule, next, now)
+	job.mu.Unlock()
+
+	return time.Time{}
+}
+
+func (s *Scheduler) run(ctx context.Context, job *scheduledJob, scheduled time.Time) {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+
+	if job.status.Running > 0 && job.opts.Overlap == SkipOverlap {
+		job.status.Skipped++
+		return
+	}
+
+	start := s.clock.Now()
+
+	job.status.Running++
+	job.status.Runs++
+	job.status.LastStart = start
+
+	s.runs.Add(1)
+
+	go func() {
+		defer s.runs.Done()
+
+		err := Traced("scheduler.run", Params{"job": job.name, "scheduled": scheduled.Format(time.RFC3339)}, job.callback)(ctx)
+
+		job.mu.Lock()
+		defer job.mu.Unlock()
+
+		job.status.Running--
+		job.status.LastDuration = s.clock.Now().Sub(start)
+		job.status.LastError = ""
+
+		if err != nil {
+			job.status.LastError = err.Error()
+		}
+	}()
+}
@@ -0,0 +1,309 @@
+package talker_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Arsfiqball/csverse/talker"
+)
+
+// waitFor polls the condition, since scheduled runs complete in their own goroutine.
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition is not met")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("interval", func(t *testing.T) {
+		clock := talker.NewFakeClock(start)
+		scheduler := talker.NewScheduler(clock)
+		runs := make(chan struct{}, 10)
+
+		err := scheduler.Add("tick", talker.Every(time.Minute), func(ctx context.Context) error {
+			runs <- struct{}{}
+			return nil
+		}, talker.JobOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := scheduler.Start(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		clock.BlockUntil(1)
+
+		if status := scheduler.Status()[0]; !status.Next.Equal(start.Add(time.Minute)) || status.Runs != 0 {
+			t.Fatalf("unexpected status: %+v", status)
+		}
+
+		clock.Advance(time.Minute)
+		<-runs
+
+		clock.BlockUntil(1)
+		clock.Advance(time.Minute)
+		<-runs
+
+		waitFor(t, func() bool { return scheduler.Status()[0].Running == 0 })
+
+		status := scheduler.Status()[0]
+		if status.Runs != 2 || !status.LastStart.Equal(start.Add(2*time.Minute)) || !status.Next.Equal(start.Add(3*time.Minute)) {
+			t.Fatalf("unexpected status: %+v", status)
+		}
+
+		if err := scheduler.Stop(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("no overlap", func(t *testing.T) {
+		clock := talker.NewFakeClock(start)
+		scheduler := talker.NewScheduler(clock)
+		release := make(chan struct{})
+
+		_ = scheduler.Add("slow", talker.Every(time.Minute), func(ctx context.Context) error {
+			<-release
+			return nil
+		}, talker.JobOptions{})
+
+		_ = scheduler.Start(context.Background())
+
+		clock.BlockUntil(1)
+		clock.Advance(time.Minute)
+		clock.BlockUntil(1)
+		clock.Advance(time.Minute)
+		clock.BlockUntil(1)
+
+		status := scheduler.Status()[0]
+		if status.Runs != 1 || status.Skipped != 1 || status.Running != 1 {
+			t.Fatalf("unexpected status: %+v", status)
+		}
+
+		close(release)
+
+		if err := scheduler.Stop(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("missed runs", func(t *testing.T) {
+		for _, policy := range []talker.MissedPolicy{talker.SkipMissed, talker.RunOnceMissed} {
+			clock := talker.NewFakeClock(start)
+			scheduler := talker.NewScheduler(clock)
+
+			_ = scheduler.Add("job", talker.Every(time.Minute), func(ctx context.Context) error {
+				return nil
+			}, talker.JobOptions{Missed: policy})
+
+			_ = scheduler.Start(context.Background())
+
+			clock.BlockUntil(1)
+			clock.Advance(5 * time.Minute) // e.g. the machine was suspended
+			clock.BlockUntil(1)
+
+			_ = scheduler.Stop(context.Background())
+
+			status := scheduler.Status()[0]
+			expectedRuns := 0
+			if policy == talker.RunOnceMissed {
+				expectedRuns = 1
+			}
+
+			if status.Missed != 1 || status.Runs != expectedRuns || !status.Next.Equal(start.Add(6*time.Minute)) {
+				t.Fatalf("unexpected status with policy %d: %+v", policy, status)
+			}
+		}
+	})
+
+	t.Run("cron with location", func(t *testing.T) {
+		clock := talker.NewFakeClock(start)
+		scheduler := talker.NewScheduler(clock)
+		wib := time.FixedZone("WIB", 7*60*60)
+
+		err := scheduler.Cron("report", "0 9 * * *", func(ctx context.Context) error { return nil }, talker.JobOptions{Location: wib})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_ = scheduler.Start(context.Background())
+		defer scheduler.Stop(context.Background())
+
+		clock.BlockUntil(1)
+
+		if next := scheduler.Status()[0].Next; !next.Equal(time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC)) {
+			t.Fatalf("unexpected next: %s", next)
+		}
+	})
+
+	t.Run("daylight saving fall-back", func(t *testing.T) {
+		chicago, err := time.LoadLocation("America/Chicago")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		fallBack := time.Date(2024, 11, 3, 7, 30, 0, 0, time.UTC) // 01:30 CST, the second 01:30 of the day
+		clock := talker.NewFakeClock(fallBack)
+		scheduler := talker.NewScheduler(clock)
+
+		_ = scheduler.Cron("job", "* * * * *", func(ctx context.Context) error { return nil }, talker.JobOptions{Location: chicago})
+		_ = scheduler.Start(context.Background())
+
+		clock.BlockUntil(1)
+
+		if next := scheduler.Status()[0].Next; !next.Equal(fallBack.Add(time.Minute)) {
+			t.Fatalf("unexpected next: %s", next)
+		}
+
+		clock.Advance(time.Minute)
+		waitFor(t, func() bool { return scheduler.Status()[0].Runs == 1 })
+		clock.BlockUntil(1)
+
+		_ = scheduler.Stop(context.Background())
+
+		if status := scheduler.Status()[0]; status.Missed != 0 || !status.Next.Equal(fallBack.Add(2*time.Minute)) {
+			t.Fatalf("unexpected status: %+v", status)
+		}
+	})
+
+	t.Run("schedule not moving forward", func(t *testing.T) {
+		clock := talker.NewFakeClock(start)
+		scheduler := talker.NewScheduler(clock)
+
+		_ = scheduler.Add("stuck", stuckSchedule{}, func(ctx context.Context) error { return nil }, talker.JobOptions{})
+		_ = scheduler.Start(context.Background())
+
+		waitFor(t, func() bool { return scheduler.Status()[0].LastError != "" })
+
+		if err := scheduler.Stop(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		if status := scheduler.Status()[0]; status.Runs != 0 || status.Missed != 0 || !status.Next.IsZero() {
+			t.Fatalf("unexpected status: %+v", status)
+		}
+	})
+
+	t.Run("traced runs", func(t *testing.T) {
+		spans := &spanRecorder{}
+		events := &eventRecorder{}
+		pwr := talker.NewPower().WithSpanHook(spans.hook).WithEventHook(events.hook)
+
+		clock := talker.NewFakeClock(start)
+		scheduler := talker.NewScheduler(clock).WithPower(pwr)
+		errFailed := talker.NewError("FAILED", "failed")
+
+		_ = scheduler.Add("job", talker.Every(time.Minute), func(ctx context.Context) error {
+			return errFailed
+		}, talker.JobOptions{})
+
+		_ = scheduler.Start(context.Background()) // like Serve, without a Power
+
+		clock.BlockUntil(1)
+		clock.Advance(time.Minute)
+		waitFor(t, func() bool { return scheduler.Status()[0].Running == 0 && scheduler.Status()[0].Runs == 1 })
+
+		if err := scheduler.Stop(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		if spans.sorted() != "scheduler.run" {
+			t.Fatalf("unexpected spans: %s", spans.sorted())
+		}
+
+		if e, ok := events.find("scheduler.run.error"); !ok || e.attrs["error"].([]talker.ErrorData)[0].Code != "FAILED" {
+			t.Fatal("error event is not sent")
+		}
+
+		if status := scheduler.Status()[0]; status.LastError != "failed" {
+			t.Fatalf("unexpected status: %+v", status)
+		}
+	})
+
+	t.Run("stop timeout", func(t *testing.T) {
+		clock := talker.NewFakeClock(start)
+		scheduler := talker.NewScheduler(clock)
+		cancelled := make(chan struct{})
+
+		_ = scheduler.Add("stuck", talker.Every(time.Minute), func(ctx context.Context) error {
+			<-ctx.Done()
+			close(cancelled)
+			return ctx.Err()
+		}, talker.JobOptions{})
+
+		_ = scheduler.Start(context.Background())
+
+		clock.BlockUntil(1)
+		clock.Advance(time.Minute)
+		waitFor(t, func() bool { return scheduler.Status()[0].Running == 1 })
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		if err := scheduler.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		<-cancelled
+	})
+
+	t.Run("add", func(t *testing.T) {
+		scheduler := talker.NewScheduler(talker.NewFakeClock(start))
+		noop := func(ctx context.Context) error { return nil }
+
+		if err := scheduler.Cron("bad", "* *", noop, talker.JobOptions{}); !errors.Is(err, talker.ErrCronInvalid) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		_ = scheduler.Add("job", talker.Every(time.Minute), noop, talker.JobOptions{})
+
+		if err := scheduler.Add("job", talker.Every(time.Minute), noop, talker.JobOptions{}); !errors.Is(err, talker.ErrSchedulerJob) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		_ = scheduler.Start(context.Background())
+		defer scheduler.Stop(context.Background())
+
+		if err := scheduler.Add("late", talker.Every(time.Minute), noop, talker.JobOptions{}); !errors.Is(err, talker.ErrSchedulerJob) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("serve", func(t *testing.T) {
+		scheduler := talker.NewScheduler(talker.NewFakeClock(start))
+		_ = scheduler.Add("job", talker.Every(time.Hour), func(ctx context.Context) error { return nil }, talker.JobOptions{})
+
+		rec := httptest.NewRecorder()
+		scheduler.ServeHTTP(rec, httptest.NewRequest("GET", "/scheduler", nil))
+
+		var statuses []map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &statuses); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(statuses) != 1 || statuses[0]["name"] != "job" || statuses[0]["schedule"] != "@every 1h0m0s" {
+			t.Fatalf("unexpected statuses: %s", rec.Body.String())
+		}
+	})
+}
+
+// stuckSchedule always returns its input, like a broken Schedule.
+type stuckSchedule struct{}
+
+func (stuckSchedule) Next(t time.Time) time.Time { return t }
+
+func (stuckSchedule) String() string { return "stuck" }
@@ -0,0 +1,454 @@
+package talker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrEventSchema is the error returned when an event payload does not match its schema.
+var ErrEventSchema = NewError("EVENT_SCHEMA", "event payload does not match its schema")
+
+// SchemaMode decides what happens when an emitted event does not match its schema.
+type SchemaMode int
+
+const (
+	FlagInvalid   SchemaMode = iota // FlagInvalid sends the event with a "schema.violations" param.
+	RejectInvalid                   // RejectInvalid does not send the event and returns ErrEventSchema.
+)
+
+// EventRegistry keeps the schema of every event defined with DefineEvent or DefineEventIn.
+// This struct must be created with the NewEventRegistry function.
+type EventRegistry struct {
+	mu     sync.RWMutex
+	mode   SchemaMode
+	events map[string]*eventSchema
+}
+
+type eventSchema struct {
+	goType reflect.Type
+	schema *jsonSchema
+}
+
+type jsonSchema struct {
+	Ref                  string                 `json:"$ref,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+
+	target *jsonSchema // target is the schema referenced by Ref.
+}
+
+// DefaultEventRegistry is the registry used by DefineEvent, it flags invalid events.
+var DefaultEventRegistry = NewEventRegistry(FlagInvalid)
+
+// NewEventRegistry creates a new EventRegistry with the given mode.
+func NewEventRegistry(mode SchemaMode) *EventRegistry {
+	return &EventRegistry{mode: mode, events: map[string]*eventSchema{}}
+}
+
+// Names returns the names of every defined event, sorted.
+func (r *EventRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.events))
+	for name := range r.events {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// JSONSchema returns a JSON Schema document with the schema of every defined event in "$defs".
+func (r *EventRegistry) JSONSchema() ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	defs := make(map[string]*jsonSchema, len(r.events))
+	for name, event := range r.events {
+		defs[name] = event.schema
+	}
+
+	return json.MarshalIndent(map[string]any{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$defs":   defs,
+	}, "", "  ")
+}
+
+// Validate checks the params of an event against the schema of the event with the given name.
+// Events that are not defined are always valid.
+func (r *EventRegistry) Validate(name string, params map[string]any) error {
+	r.mu.RLock()
+	event, ok := r.events[name]
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil
+	}
+
+	violations := validateSchema(event.schema, "", params)
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return ErrEventSchema.
+		WithInfo(fmt.Sprintf("event %s does not match its schema: %s", name, strings.Join(violations, "; "))).
+		WithData(violations)
+}
+
+func (r *EventRegistry) register(name string, t reflect.Type) *eventSchema {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if event, ok := r.events[name]; ok {
+		if event.goType != t {
+			panic(fmt.Sprintf("talker: event %s is already defined with type %s", name, event.goType))
+		}
+
+		return event
+	}
+
+	event := &eventSchema{goType: t, schema: schemaOf(t, "#/$defs/"+schemaPointerEscaper.Replace(name), map[reflect.Type]*jsonSchema{})}
+	r.events[name] = event
+
+	return event
+}
+
+// EventDef is a typed event definition, its payload is converted to params by reflection.
+// Struct fields are named by the "param" tag, e.g. `param:"user_id,required"`,
+// the field name is used when there is no tag and fields tagged with "-" are skipped.
+// The "omitempty" option omits zero values and the "required" option rejects or flags zero values.
+// This struct must be created with the DefineEvent or DefineEventIn function.
+type EventDef[T any] struct {
+	name     string
+	registry *EventRegistry
+}
+
+// DefineEvent defines a typed event in the DefaultEventRegistry.
+// Recursive payload types are supported, a struct nested in itself is a "$ref" to its outer schema.
+// It panics when the name is already defined with another type.
+// Example:
+//
+//	type UserSignup struct {
+//		UserID int    `param:"user_id,required"`
+//		Plan   string `param:"plan,omitempty"`
+//	}
+//
+//	var EventUserSignup = talker.DefineEvent[UserSignup]("user.signup")
+//
+//	func signup(ctx context.Context) error {
+//		// ... do something
+//		return EventUserSignup.Emit(ctx, UserSignup{UserID: 42, Plan: "pro"})
+//	}
+func DefineEvent[T any](name string) EventDef[T] {
+	return DefineEventIn[T](DefaultEventRegistry, name)
+}
+
+// DefineEventIn defines a typed event in the given registry.
+// It panics when the name is already defined with another type.
+func DefineEventIn[T any](registry *EventRegistry, name string) EventDef[T] {
+	registry.register(name, reflect.TypeOf((*T)(nil)).Elem())
+
+	return EventDef[T]{name: name, registry: registry}
+}
+
+// Name returns the name of the event.
+func (d EventDef[T]) Name() string {
+	return d.name
+}
+
+// Params converts the payload to params.
+func (d EventDef[T]) Params(payload T) Params {
+	switch params := paramsOf(reflect.ValueOf(&payload).Elem()).(type) {
+	case Params:
+		return params
+	case map[string]any:
+		return params
+	}
+
+	return nil
+}
+
+// Emit sends the payload as an Event.
+// When the payload does not match the schema, the registry mode decides whether the event is flagged
+// with a "schema.violations" param or rejected, the ErrEventSchema error is returned in both cases.
+func (d EventDef[T]) Emit(ctx context.Context, payload T) error {
+	params := d.Params(payload)
+
+	err := d.registry.Validate(d.name, params)
+	if err != nil {
+		if d.registry.mode == RejectInvalid {
+			return err
+		}
+
+		if params == nil {
+			params = Params{}
+		}
+
+		params["schema.violations"] = err.(Error).Data()
+	}
+
+	Event(ctx, d.name, params)
+
+	return err
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+type paramField struct {
+	index     int
+	name      string
+	required  bool
+	omitEmpty bool
+}
+
+func paramFields(t reflect.Type) []paramField {
+	fields := []paramField{}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		tag := f.Tag.Get("param")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = f.Name
+		}
+
+		field := paramField{index: i, name: name}
+
+		for _, opt := range strings.Split(opts, ",") {
+			switch opt {
+			case "required":
+				field.required = true
+			case "omitempty":
+				field.omitEmpty = true
+			}
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields
+}
+
+func isStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != timeType
+}
+
+// hasStruct reports whether the values of t are converted with their param tags, i.e. t contains structs.
+func hasStruct(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		return hasStruct(t.Elem())
+	}
+
+	return isStruct(t) || t.Kind() == reflect.Interface
+}
+
+var schemaPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// schemaOf returns the schema of t located at the JSON pointer,
+// visiting holds the structs being built so recursive types become references.
+func schemaOf(t reflect.Type, pointer string, visiting map[reflect.Type]*jsonSchema) *jsonSchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if outer, ok := visiting[t]; ok {
+		return outer
+	}
+
+	if t == timeType {
+		return &jsonSchema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &jsonSchema{Type: "string"}
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &jsonSchema{Type: "array", Items: schemaOf(t.Elem(), pointer+"/items", visiting)}
+	case reflect.Map:
+		return &jsonSchema{Type: "object"}
+	case reflect.Struct:
+		closed := false
+		schema := &jsonSchema{Type: "object", Properties: map[string]*jsonSchema{}, AdditionalProperties: &closed}
+
+		visiting[t] = &jsonSchema{Ref: pointer, target: schema}
+		defer delete(visiting, t)
+
+		for _, field := range paramFields(t) {
+			schema.Properties[field.name] = schemaOf(t.Field(field.index).Type, pointer+"/properties/"+schemaPointerEscaper.Replace(field.name), visiting)
+
+			if field.required {
+				schema.Required = append(schema.Required, field.name)
+			}
+		}
+
+		return schema
+	}
+
+	return &jsonSchema{}
+}
+
+func paramsOf(v reflect.Value) any {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+
+		v = v.Elem()
+	}
+
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && hasStruct(v.Type().Elem()) {
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+
+		items := make([]any, v.Len())
+		for i := range items {
+			items[i] = paramsOf(v.Index(i))
+		}
+
+		return items
+	}
+
+	if !isStruct(v.Type()) {
+		return v.Interface()
+	}
+
+	params := Params{}
+
+	for _, field := range paramFields(v.Type()) {
+		fv := v.Field(field.index)
+
+		if fv.IsZero() && (field.omitEmpty || field.required) {
+			continue
+		}
+
+		params[field.name] = paramsOf(fv)
+	}
+
+	return params
+}
+
+func validateSchema(schema *jsonSchema, path string, value any) []string {
+	if value == nil {
+		return nil
+	}
+
+	if schema.target != nil {
+		schema = schema.target
+	}
+
+	violations := []string{}
+	rv := reflect.ValueOf(value)
+
+	switch schema.Type {
+	case "string":
+		_, isTime := value.(time.Time)
+		if rv.Kind() != reflect.String && !isTime {
+			violations = append(violations, fmt.Sprintf("%s must be a string", path))
+		}
+	case "boolean":
+		if rv.Kind() != reflect.Bool {
+			violations = append(violations, fmt.Sprintf("%s must be a boolean", path))
+		}
+	case "integer":
+		if !rv.CanInt() && !rv.CanUint() && !(rv.CanFloat() && rv.Float() == float64(int64(rv.Float()))) {
+			violations = append(violations, fmt.Sprintf("%s must be an integer", path))
+		}
+	case "number":
+		if !rv.CanInt() && !rv.CanUint() && !rv.CanFloat() {
+			violations = append(violations, fmt.Sprintf("%s must be a number", path))
+		}
+	case "array":
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			violations = append(violations, fmt.Sprintf("%s must be an array", path))
+			break
+		}
+
+		if schema.Items == nil {
+			break
+		}
+
+		for i := 0; i < rv.Len(); i++ {
+			violations = append(violations, validateSchema(schema.Items, fmt.Sprintf("%s[%d]", path, i), rv.Index(i).Interface())...)
+		}
+	case "object":
+		if schema.Properties == nil {
+			if rv.Kind() != reflect.Map && rv.Kind() != reflect.Struct {
+				violations = append(violations, fmt.Sprintf("%s must be an object", path))
+			}
+
+			break
+		}
+
+		params, ok := value.(map[string]any)
+		if !ok {
+			params, ok = value.(Params)
+		}
+
+		if !ok {
+			violations = append(violations, fmt.Sprintf("%s must be an object", path))
+			break
+		}
+
+		prefix := ""
+		if path != "" {
+			prefix = path + "."
+		}
+
+		for _, name := range schema.Required {
+			if _, ok := params[name]; !ok {
+				violations = append(violations, fmt.Sprintf("%s%s is required", prefix, name))
+			}
+		}
+
+		keys := make([]string, 0, len(params))
+		for key := range params {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			property, ok := schema.Properties[key]
+			if !ok {
+				violations = append(violations, fmt.Sprintf("%s%s is not defined", prefix, key))
+				continue
+			}
+
+			violations = append(violations, validateSchema(property, prefix+key, params[key])...)
+		}
+	}
+
+	return violations
+}
@@ -0,0 +1,229 @@
+package talker_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Arsfiqball/csverse/talker"
+)
+
+type userSignup struct {
+	UserID  int       `param:"user_id,required"`
+	Plan    string    `param:"plan,omitempty"`
+	At      time.Time `param:"at"`
+	Address struct {
+		City string `param:"city"`
+	} `param:"address"`
+	Secret string `param:"-"`
+}
+
+type treeNode struct {
+	Name     string      `param:"name,required"`
+	Children []*treeNode `param:"children,omitempty"`
+}
+
+type orderPlaced struct {
+	Items []struct {
+		SKU string `param:"sku,required"`
+		Qty int    `param:"qty"`
+	} `param:"items"`
+}
+
+func TestDefineEvent(t *testing.T) {
+	t.Run("emit", func(t *testing.T) {
+		rec := &eventRecorder{}
+		ctx := talker.NewPower().WithEventHook(rec.hook).Context(context.Background(), "test")
+
+		registry := talker.NewEventRegistry(talker.FlagInvalid)
+		signup := talker.DefineEventIn[userSignup](registry, "user.signup")
+
+		payload := userSignup{UserID: 42, Secret: "hidden"}
+		payload.Address.City = "Jakarta"
+
+		if err := signup.Emit(ctx, payload); err != nil {
+			t.Fatal(err)
+		}
+
+		e, ok := rec.find("user.signup")
+		if !ok {
+			t.Fatal("event is not sent")
+		}
+
+		if e.attrs["user_id"] != 42 || e.attrs["address"].(talker.Params)["city"] != "Jakarta" {
+			t.Fatalf("unexpected params: %v", e.attrs)
+		}
+
+		if _, ok := e.attrs["plan"]; ok {
+			t.Fatal("empty field is not omitted")
+		}
+
+		if _, ok := e.attrs["Secret"]; ok {
+			t.Fatal("skipped field is sent")
+		}
+	})
+
+	t.Run("flag", func(t *testing.T) {
+		rec := &eventRecorder{}
+		ctx := talker.NewPower().WithEventHook(rec.hook).Context(context.Background(), "test")
+
+		signup := talker.DefineEventIn[userSignup](talker.NewEventRegistry(talker.FlagInvalid), "user.signup")
+
+		if err := signup.Emit(ctx, userSignup{}); !errors.Is(err, talker.ErrEventSchema) {
+			t.Fatal("missing required field is not reported")
+		}
+
+		e, ok := rec.find("user.signup")
+		if !ok || e.attrs["schema.violations"] == nil {
+			t.Fatal("invalid event is not flagged")
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		rec := &eventRecorder{}
+		ctx := talker.NewPower().WithEventHook(rec.hook).Context(context.Background(), "test")
+
+		signup := talker.DefineEventIn[userSignup](talker.NewEventRegistry(talker.RejectInvalid), "user.signup")
+
+		if err := signup.Emit(ctx, userSignup{}); !errors.Is(err, talker.ErrEventSchema) {
+			t.Fatal("missing required field is not reported")
+		}
+
+		if _, ok := rec.find("user.signup"); ok {
+			t.Fatal("invalid event is sent")
+		}
+	})
+
+	t.Run("validate", func(t *testing.T) {
+		registry := talker.NewEventRegistry(talker.FlagInvalid)
+		talker.DefineEventIn[userSignup](registry, "user.signup")
+
+		if err := registry.Validate("user.signup", talker.Params{"user_id": 1.0, "at": time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := registry.Validate("user.signup", talker.Params{"user_id": "1", "extra": true}); err == nil {
+			t.Fatal("invalid params are accepted")
+		}
+
+		if err := registry.Validate("unknown", talker.Params{"anything": 1}); err != nil {
+			t.Fatal("undefined event is rejected")
+		}
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		registry := talker.NewEventRegistry(talker.FlagInvalid)
+		talker.DefineEventIn[userSignup](registry, "user.signup")
+		talker.DefineEventIn[userSignup](registry, "user.signup") // same type is fine
+
+		defer func() {
+			if recover() == nil {
+				t.Fatal("conflicting definition does not panic")
+			}
+		}()
+
+		talker.DefineEventIn[struct{}](registry, "user.signup")
+	})
+
+	t.Run("json schema", func(t *testing.T) {
+		registry := talker.NewEventRegistry(talker.FlagInvalid)
+		talker.DefineEventIn[userSignup](registry, "user.signup")
+
+		b, err := registry.JSONSchema()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var doc struct {
+			Defs map[string]struct {
+				Type       string `json:"type"`
+				Required   []string
+				Properties map[string]struct {
+					Type   string `json:"type"`
+					Format string `json:"format"`
+				}
+			} `json:"$defs"`
+		}
+
+		if err := json.Unmarshal(b, &doc); err != nil {
+			t.Fatal(err)
+		}
+
+		def := doc.Defs["user.signup"]
+
+		if def.Type != "object" || len(def.Required) != 1 || def.Required[0] != "user_id" {
+			t.Fatalf("unexpected schema: %s", b)
+		}
+
+		if def.Properties["user_id"].Type != "integer" || def.Properties["at"].Format != "date-time" || def.Properties["address"].Type != "object" {
+			t.Fatalf("unexpected properties: %s", b)
+		}
+	})
+
+	t.Run("recursive type", func(t *testing.T) {
+		registry := talker.NewEventRegistry(talker.RejectInvalid)
+		tree := talker.DefineEventIn[treeNode](registry, "tree.built")
+
+		b, err := registry.JSONSchema()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var doc struct {
+			Defs map[string]struct {
+				Properties map[string]struct {
+					Items struct {
+						Ref string `json:"$ref"`
+					} `json:"items"`
+				} `json:"properties"`
+			} `json:"$defs"`
+		}
+
+		if err := json.Unmarshal(b, &doc); err != nil {
+			t.Fatal(err)
+		}
+
+		if ref := doc.Defs["tree.built"].Properties["children"].Items.Ref; ref != "#/$defs/tree.built" {
+			t.Fatalf("unexpected schema: %s", b)
+		}
+
+		valid := treeNode{Name: "root", Children: []*treeNode{{Name: "leaf"}}}
+		if err := tree.Emit(context.Background(), valid); err != nil {
+			t.Fatal(err)
+		}
+
+		invalid := treeNode{Name: "root", Children: []*treeNode{{Children: []*treeNode{{Name: "leaf"}}}}}
+		if err := tree.Emit(context.Background(), invalid); !errors.Is(err, talker.ErrEventSchema) {
+			t.Fatalf("nested violation is not rejected: %v", err)
+		}
+	})
+
+	t.Run("slice of structs", func(t *testing.T) {
+		registry := talker.NewEventRegistry(talker.RejectInvalid)
+		order := talker.DefineEventIn[orderPlaced](registry, "order.placed")
+
+		payload := orderPlaced{}
+		payload.Items = append(payload.Items, struct {
+			SKU string `param:"sku,required"`
+			Qty int    `param:"qty"`
+		}{SKU: "A1", Qty: 2})
+
+		items, ok := order.Params(payload)["items"].([]any)
+		if !ok || len(items) != 1 || items[0].(talker.Params)["sku"] != "A1" || items[0].(talker.Params)["qty"] != 2 {
+			t.Fatalf("unexpected params: %v", order.Params(payload))
+		}
+
+		if err := order.Emit(context.Background(), payload); err != nil {
+			t.Fatal(err)
+		}
+
+		payload.Items[0].SKU = ""
+
+		err := order.Emit(context.Background(), payload)
+		if !errors.Is(err, talker.ErrEventSchema) || err.(talker.Error).Data().([]string)[0] != "items[0].sku is required" {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
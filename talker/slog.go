@@ -0,0 +1,148 @@
+package talker
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// slogContextKey marks the context of records written by SlogHook,
+// so an EventHandler does not send them back to the Power.
+const slogContextKey = PowerContextKey("slog_context")
+
+type eventHandler struct {
+	level  slog.Leveler
+	prefix string
+	attrs  Params
+}
+
+var _ slog.Handler = eventHandler{}
+
+// NewEventHandler creates a slog.Handler that sends every record to the Power in the record context
+// as an Event named after the message, with the level and attributes as params.
+// Grouped attributes are flattened to dotted keys, e.g. "request.id".
+// Records below the given level are ignored, slog.LevelInfo is used when it is nil.
+// Example:
+//
+//	logger := slog.New(talker.NewEventHandler(slog.LevelDebug))
+//	logger.InfoContext(ctx, "user.signup", "user_id", 42) // talker.Event(ctx, "user.signup", {"level": "INFO", "user_id": 42})
+func NewEventHandler(level slog.Leveler) slog.Handler {
+	if level == nil {
+		level = slog.LevelInfo
+	}
+
+	return eventHandler{level: level, attrs: Params{}}
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h eventHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+// Handle sends the record as an Event.
+func (h eventHandler) Handle(ctx context.Context, record slog.Record) error {
+	if ctx == nil || ctx.Value(slogContextKey) != nil {
+		return nil
+	}
+
+	params := make(Params, len(h.attrs)+record.NumAttrs()+1)
+
+	for key, value := range h.attrs {
+		params[key] = value
+	}
+
+	record.Attrs(func(attr slog.Attr) bool {
+		flattenSlogAttr(params, h.prefix, attr)
+		return true
+	})
+
+	params["level"] = record.Level.String()
+
+	Event(ctx, record.Message, params)
+
+	return nil
+}
+
+// WithAttrs returns a new handler with the given attributes.
+func (h eventHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	params := make(Params, len(h.attrs)+len(attrs))
+
+	for key, value := range h.attrs {
+		params[key] = value
+	}
+
+	for _, attr := range attrs {
+		flattenSlogAttr(params, h.prefix, attr)
+	}
+
+	h.attrs = params
+
+	return h
+}
+
+// WithGroup returns a new handler with the given group.
+func (h eventHandler) WithGroup(name string) slog.Handler {
+	if name != "" {
+		h.prefix += name + "."
+	}
+
+	return h
+}
+
+func flattenSlogAttr(params Params, prefix string, attr slog.Attr) {
+	value := attr.Value.Resolve()
+
+	if value.Kind() != slog.KindGroup {
+		if attr.Key != "" {
+			params[prefix+attr.Key] = value.Any()
+		}
+
+		return
+	}
+
+	if attr.Key != "" {
+		prefix += attr.Key + "."
+	}
+
+	for _, child := range value.Group() {
+		flattenSlogAttr(params, prefix, child)
+	}
+}
+
+// SlogHook returns an EventHook that writes every event to the slog.Handler as a record
+// with the event name as message and the params as attributes.
+// The record level is taken from the "level" param and defaults to slog.LevelInfo.
+// Example:
+//
+//	pwr := talker.NewPower().WithEventHook(talker.SlogHook(slog.NewJSONHandler(os.Stdout, nil)))
+func SlogHook(handler slog.Handler) EventHook {
+	return func(ctx context.Context, name string, attrs map[string]any) {
+		level := slog.LevelInfo
+
+		if text, ok := attrs["level"].(string); ok {
+			_ = level.UnmarshalText([]byte(text))
+		}
+
+		if !handler.Enabled(ctx, level) {
+			return
+		}
+
+		keys := make([]string, 0, len(attrs))
+		for key := range attrs {
+			if key != "level" {
+				keys = append(keys, key)
+			}
+		}
+
+		sort.Strings(keys)
+
+		record := slog.NewRecord(time.Now(), level, name, 0)
+
+		for _, key := range keys {
+			record.AddAttrs(slog.Any(key, attrs[key]))
+		}
+
+		_ = handler.Handle(context.WithValue(ctx, slogContextKey, true), record)
+	}
+}
@@ -0,0 +1,64 @@
+package talker_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/Arsfiqball/csverse/talker"
+)
+
+func TestEventHandler(t *testing.T) {
+	rec := &eventRecorder{}
+	ctx := talker.NewPower().WithEventHook(rec.hook).Context(context.Background(), "test")
+
+	logger := slog.New(talker.NewEventHandler(slog.LevelInfo)).With("service", "api").WithGroup("req")
+
+	logger.DebugContext(ctx, "ignored")
+	logger.WarnContext(ctx, "user.signup", "id", 42, slog.Group("client", "ip", "127.0.0.1"))
+
+	if _, ok := rec.find("ignored"); ok {
+		t.Fatal("record below level is sent")
+	}
+
+	e, ok := rec.find("user.signup")
+	if !ok {
+		t.Fatal("record is not sent as event")
+	}
+
+	if e.attrs["level"] != "WARN" || e.attrs["service"] != "api" || e.attrs["req.id"] != int64(42) || e.attrs["req.client.ip"] != "127.0.0.1" {
+		t.Fatalf("unexpected params: %v", e.attrs)
+	}
+}
+
+func TestSlogHook(t *testing.T) {
+	t.Run("write", func(t *testing.T) {
+		var buf bytes.Buffer
+
+		ctx := talker.NewPower().
+			WithEventHook(talker.SlogHook(slog.NewTextHandler(&buf, nil))).
+			Context(context.Background(), "test")
+
+		talker.Event(ctx, "user.signup", talker.Params{"level": "ERROR", "user_id": 42})
+
+		if !strings.Contains(buf.String(), "level=ERROR msg=user.signup user_id=42") {
+			t.Fatalf("unexpected log: %s", buf.String())
+		}
+	})
+
+	t.Run("no loop", func(t *testing.T) {
+		count := 0
+
+		pwr := talker.NewPower().
+			WithEventHook(func(ctx context.Context, name string, attrs map[string]any) { count++ }).
+			WithEventHook(talker.SlogHook(talker.NewEventHandler(nil)))
+
+		talker.Event(pwr.Context(context.Background(), "test"), "ping", nil)
+
+		if count != 1 {
+			t.Fatalf("expected 1 event, got %d", count)
+		}
+	})
+}
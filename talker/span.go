@@ -0,0 +1,234 @@
+package talker
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// SpanParamsHook is a function that can be used to hook into the SpanHandle.SetParams method.
+type SpanParamsHook func(ctx context.Context, name string, params map[string]any)
+
+// SpanErrorHook is a function that can be used to hook into the SpanHandle.RecordError method.
+type SpanErrorHook func(ctx context.Context, name string, err error)
+
+// SpanLinkHook is a function that can be used to hook into the SpanHandle.AddLink method.
+type SpanLinkHook func(ctx context.Context, name string, link SpanHandle)
+
+const spanContextKey = PowerContextKey("span_context")
+
+var lastSpanID atomic.Uint64
+
+type spanState struct {
+	id     uint64
+	name   string
+	parent *spanState
+	pwr    Power
+
+	mu     sync.Mutex
+	ctx    context.Context // ctx is the context returned by Span, with the values added by every SpanHook.
+	params Params
+	links  []SpanHandle
+}
+
+// SpanHandle is a handle to a recorded span, it is returned by the SpanFromContext function.
+// The zero SpanHandle is valid and does nothing, it is returned when there is no recorded span.
+type SpanHandle struct {
+	s *spanState
+}
+
+// SpanFromContext returns a handle to the span the context runs inside.
+// Example:
+//
+//	func chargeCard(ctx context.Context, amount int) error {
+//		span := talker.SpanFromContext(ctx)
+//		span.SetParams(talker.Params{"amount": amount})
+//
+//		if err := gateway.Charge(ctx, amount); err != nil {
+//			span.RecordError(err)
+//			return err
+//		}
+//
+//		return nil
+//	}
+func SpanFromContext(ctx context.Context) SpanHandle {
+	if !Sampled(ctx) {
+		return SpanHandle{}
+	}
+
+	s, _ := ctx.Value(spanContextKey).(*spanState)
+
+	return SpanHandle{s: s}
+}
+
+func startSpanState(ctx context.Context, pwr Power, name string, params Params) (context.Context, *spanState) {
+	parent, _ := ctx.Value(spanContextKey).(*spanState)
+
+	s := &spanState{
+		id:     lastSpanID.Add(1),
+		name:   name,
+		parent: parent,
+		pwr:    pwr,
+		params: make(Params, len(params)),
+	}
+
+	for key, value := range params {
+		s.params[key] = value
+	}
+
+	ctx = context.WithValue(ctx, spanContextKey, s)
+	s.ctx = ctx
+
+	return ctx, s
+}
+
+func (s *spanState) setContext(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ctx = ctx
+}
+
+func (s *spanState) context() context.Context {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.ctx
+}
+
+// Recording reports whether the handle refers to a recorded span.
+func (h SpanHandle) Recording() bool {
+	return h.s != nil
+}
+
+// ID returns the process unique ID of the span, 0 when the handle is not recording.
+func (h SpanHandle) ID() uint64 {
+	if h.s == nil {
+		return 0
+	}
+
+	return h.s.id
+}
+
+// Name returns the name of the span.
+func (h SpanHandle) Name() string {
+	if h.s == nil {
+		return ""
+	}
+
+	return h.s.name
+}
+
+// Parent returns a handle to the parent span.
+func (h SpanHandle) Parent() SpanHandle {
+	if h.s == nil {
+		return SpanHandle{}
+	}
+
+	return SpanHandle{s: h.s.parent}
+}
+
+// Params returns a copy of the params of the span, including the params set with SetParams.
+func (h SpanHandle) Params() Params {
+	if h.s == nil {
+		return nil
+	}
+
+	h.s.mu.Lock()
+	defer h.s.mu.Unlock()
+
+	params := make(Params, len(h.s.params))
+	for key, value := range h.s.params {
+		params[key] = value
+	}
+
+	return params
+}
+
+// Links returns the spans linked with AddLink.
+func (h SpanHandle) Links() []SpanHandle {
+	if h.s == nil {
+		return nil
+	}
+
+	h.s.mu.Lock()
+	defer h.s.mu.Unlock()
+
+	return append([]SpanHandle{}, h.s.links...)
+}
+
+// SetParams adds the params to the span and calls every SpanParamsHook of the Power.
+func (h SpanHandle) SetParams(params Params) {
+	if h.s == nil {
+		return
+	}
+
+	h.s.mu.Lock()
+	for key, value := range params {
+		h.s.params[key] = value
+	}
+	h.s.mu.Unlock()
+
+	ctx := h.s.context()
+
+	for _, hook := range h.s.pwr.spanParamsHooks {
+		h.s.pwr.callSpanParamsHook(hook, ctx, h.s.name, params)
+	}
+}
+
+// AddEvent sends an Event inside the span.
+func (h SpanHandle) AddEvent(name string, params Params) {
+	if h.s == nil {
+		return
+	}
+
+	Event(h.s.context(), name, params)
+}
+
+// RecordError calls every SpanErrorHook of the Power, and sends an event named after the span
+// with an ".error" suffix with the error data as the "error" param.
+func (h SpanHandle) RecordError(err error) {
+	if h.s == nil || err == nil {
+		return
+	}
+
+	ctx := h.s.context()
+
+	for _, hook := range h.s.pwr.spanErrorHooks {
+		h.s.pwr.callSpanErrorHook(hook, ctx, h.s.name, err)
+	}
+
+	Event(ctx, h.s.name+".error", Params{"error": ErrorDataFrom(err, 10)})
+}
+
+// AddLink links the span to another span, e.g. the span that queued the job the span processes.
+func (h SpanHandle) AddLink(link SpanHandle) {
+	if h.s == nil || link.s == nil {
+		return
+	}
+
+	h.s.mu.Lock()
+	h.s.links = append(h.s.links, link)
+	h.s.mu.Unlock()
+
+	ctx := h.s.context()
+
+	for _, hook := range h.s.pwr.spanLinkHooks {
+		h.s.pwr.callSpanLinkHook(hook, ctx, h.s.name, link)
+	}
+}
+
+// DetachedSpan starts a new span like Span, but the returned context is not canceled when ctx is canceled.
+// The new span is still a child of the span in ctx. Use it for background work that outlives the request.
+// Example:
+//
+//	func handle(ctx context.Context) {
+//		go func(ctx context.Context) {
+//			ctx, end := talker.DetachedSpan(ctx, "send.email", nil)
+//			defer end()
+//			// ... do something after the request is done
+//		}(ctx)
+//	}
+func DetachedSpan(ctx context.Context, name string, params Params) (context.Context, func()) {
+	return Span(context.WithoutCancel(ctx), name, params)
+}
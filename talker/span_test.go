@@ -0,0 +1,154 @@
+package talker_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Arsfiqball/csverse/talker"
+)
+
+func TestSpanFromContext(t *testing.T) {
+	t.Run("without span", func(t *testing.T) {
+		span := talker.SpanFromContext(context.Background())
+
+		// The zero handle does nothing
+		span.SetParams(talker.Params{"key": "value"})
+		span.RecordError(errors.New("failed"))
+
+		if span.Recording() || span.Params() != nil {
+			t.Fatal("zero handle is recording")
+		}
+	})
+
+	t.Run("handle", func(t *testing.T) {
+		var endParams talker.Params
+		var setParams map[string]any
+		var recorded error
+		var linked talker.SpanHandle
+
+		events := &eventRecorder{}
+
+		pwr := talker.NewPower().
+			WithSpanHook(func(ctx context.Context, name string, attrs map[string]any) (context.Context, func()) {
+				span := talker.SpanFromContext(ctx)
+				return ctx, func() { endParams = span.Params() }
+			}).
+			WithSpanParamsHook(func(ctx context.Context, name string, params map[string]any) { setParams = params }).
+			WithSpanErrorHook(func(ctx context.Context, name string, err error) { recorded = err }).
+			WithSpanLinkHook(func(ctx context.Context, name string, link talker.SpanHandle) { linked = link }).
+			WithEventHook(events.hook)
+
+		ctx := pwr.Context(context.Background(), "test")
+
+		producerCtx, endProducer := talker.Span(ctx, "producer", nil)
+		producer := talker.SpanFromContext(producerCtx)
+		endProducer()
+
+		ctx, end := talker.Span(ctx, "consumer", talker.Params{"queue": "jobs"})
+		span := talker.SpanFromContext(ctx)
+
+		func(ctx context.Context) {
+			talker.SpanFromContext(ctx).SetParams(talker.Params{"job_id": 42})
+		}(ctx)
+
+		span.AddLink(producer)
+		span.AddEvent("job.started", nil)
+		span.RecordError(errors.New("failed"))
+		end()
+
+		if endParams["queue"] != "jobs" || endParams["job_id"] != 42 {
+			t.Fatalf("unexpected params at end: %v", endParams)
+		}
+
+		if setParams["job_id"] != 42 || recorded == nil || linked.ID() != producer.ID() {
+			t.Fatal("hooks are not called")
+		}
+
+		if len(span.Links()) != 1 || span.ID() == producer.ID() {
+			t.Fatal("link is not kept")
+		}
+
+		if _, ok := events.find("job.started"); !ok {
+			t.Fatal("event is not sent")
+		}
+
+		if _, ok := events.find("consumer.error"); !ok {
+			t.Fatal("error event is not sent")
+		}
+	})
+
+	t.Run("hook context", func(t *testing.T) {
+		type backendSpan struct{}
+
+		seen := map[string]any{}
+
+		pwr := talker.NewPower().
+			WithSpanHook(func(ctx context.Context, name string, attrs map[string]any) (context.Context, func()) {
+				return context.WithValue(ctx, backendSpan{}, name), func() {}
+			}).
+			WithSpanParamsHook(func(ctx context.Context, name string, params map[string]any) {
+				seen["params"] = ctx.Value(backendSpan{})
+			}).
+			WithSpanErrorHook(func(ctx context.Context, name string, err error) { seen["error"] = ctx.Value(backendSpan{}) }).
+			WithSpanLinkHook(func(ctx context.Context, name string, link talker.SpanHandle) {
+				seen["link"] = ctx.Value(backendSpan{})
+			}).
+			WithEventHook(func(ctx context.Context, name string, attrs map[string]any) { seen[name] = ctx.Value(backendSpan{}) })
+
+		ctx, end := talker.Span(pwr.Context(context.Background(), "test"), "work", nil)
+		defer end()
+
+		span := talker.SpanFromContext(ctx)
+		span.SetParams(talker.Params{"key": "value"})
+		span.RecordError(errors.New("failed"))
+		span.AddLink(span)
+		span.AddEvent("work.step", nil)
+
+		for _, key := range []string{"params", "error", "link", "work.step", "work.error"} {
+			if seen[key] != "work" {
+				t.Fatalf("hook value is not seen by %s: %v", key, seen)
+			}
+		}
+	})
+
+	t.Run("parent and sampling", func(t *testing.T) {
+		pwr := talker.NewPower().WithSpanSampler(talker.ParentBasedSampler(talker.AlwaysSample()))
+		ctx, end := talker.Span(pwr.Context(context.Background(), "test"), "parent", nil)
+		defer end()
+
+		childCtx, endChild := talker.Span(ctx, "child", nil)
+		defer endChild()
+
+		if talker.SpanFromContext(childCtx).Parent().ID() != talker.SpanFromContext(ctx).ID() {
+			t.Fatal("parent is not linked")
+		}
+
+		dropped := talker.NewPower().WithSpanSampler(talker.NeverSample())
+		droppedCtx, endDropped := talker.Span(dropped.Context(ctx, "test"), "dropped", nil)
+		defer endDropped()
+
+		if talker.SpanFromContext(droppedCtx).Recording() {
+			t.Fatal("dropped span is recording")
+		}
+	})
+
+	t.Run("detached", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(talker.NewPower().Context(context.Background(), "test"))
+		ctx, end := talker.Span(ctx, "request", nil)
+		defer end()
+
+		detachedCtx, endDetached := talker.DetachedSpan(ctx, "background", nil)
+		defer endDetached()
+
+		cancel()
+
+		if detachedCtx.Err() != nil {
+			t.Fatal("detached span is canceled with its parent")
+		}
+
+		if talker.SpanFromContext(detachedCtx).Parent().ID() != talker.SpanFromContext(ctx).ID() {
+			t.Fatal("detached span lost its parent")
+		}
+	})
+}
@@ -0,0 +1,218 @@
+package talker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrTaskNoValue is the error returned by a lifted task when its combinator succeeded
+// without running the task for the caller, check out Lift.
+var ErrTaskNoValue = NewError("TASK_NO_VALUE", "lifted task returned no value to the caller")
+
+// Task is a function that returns a value, it is the value-returning version of Callback.
+// Tasks are composed with Then, Map, All and Any, and most Callback combinators can be used with Lift.
+type Task[T any] func(context.Context) (T, error)
+
+// FromCallback returns a Task that runs callback and returns an empty value.
+func FromCallback(callback Callback) Task[struct{}] {
+	return func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, callback(ctx)
+	}
+}
+
+// ToCallback returns a Callback that runs task and drops the value.
+func ToCallback[T any](task Task[T]) Callback {
+	return func(ctx context.Context) error {
+		_, err := task(ctx)
+		return err
+	}
+}
+
+// Then runs task, then next with its value. next is not run when task fails.
+// Example:
+//
+//	orders := talker.Then(fetchUser, func(ctx context.Context, user User) ([]Order, error) {
+//		return fetchOrders(ctx, user.ID)
+//	})
+//
+//	list, err := orders(ctx)
+func Then[T any, U any](task Task[T], next func(ctx context.Context, value T) (U, error)) Task[U] {
+	return func(ctx context.Context) (U, error) {
+		value, err := task(ctx)
+		if err != nil {
+			var zero U
+			return zero, err
+		}
+
+		return next(ctx, value)
+	}
+}
+
+// Map runs task and converts its value with fn.
+func Map[T any, U any](task Task[T], fn func(value T) U) Task[U] {
+	return func(ctx context.Context) (U, error) {
+		value, err := task(ctx)
+		if err != nil {
+			var zero U
+			return zero, err
+		}
+
+		return fn(value), nil
+	}
+}
+
+// All runs the tasks in parallel and returns their values in the order of the tasks.
+// The other tasks are cancelled on the first error, which is wrapped with ErrParallelStep (check out ParallelWith).
+// Example:
+//
+//	values, err := talker.All(fetchUser(1), fetchUser(2), fetchUser(3))(ctx)
+func All[T any](tasks ...Task[T]) Task[[]T] {
+	return func(ctx context.Context) ([]T, error) {
+		values := make([]T, len(tasks))
+		callbacks := make([]Callback, len(tasks))
+
+		for i, task := range tasks {
+			i, task := i, task
+
+			callbacks[i] = func(ctx context.Context) error {
+				value, err := task(ctx)
+				values[i] = value
+
+				return err
+			}
+		}
+
+		if err := ParallelWith(ParallelOptions{FailFast: true}, callbacks...)(ctx); err != nil {
+			return nil, err
+		}
+
+		return values, nil
+	}
+}
+
+// Any runs the tasks in parallel and returns the value of the first one that succeeds, the others are cancelled.
+// When every task fails, the errors are joined in the order of the tasks.
+// The zero value is returned without an error when there is no task.
+// Example:
+//
+//	price, err := talker.Any(priceFromCache, priceFromDatabase)(ctx)
+func Any[T any](tasks ...Task[T]) Task[T] {
+	return func(ctx context.Context) (T, error) {
+		ctx, end := combinatorSpan(ctx, "talker.any", Params{"tasks": len(tasks)})
+		defer end()
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		var (
+			wg    sync.WaitGroup
+			once  sync.Once
+			value T
+			won   bool
+		)
+
+		errs := make([]error, len(tasks))
+
+		for i, task := range tasks {
+			wg.Add(1)
+
+			go func(i int, task Task[T]) {
+				defer wg.Done()
+
+				v, err := task(ctx)
+				if err != nil {
+					errs[i] = err
+					return
+				}
+
+				once.Do(func() {
+					value, won = v, true
+					cancel()
+				})
+			}(i, task)
+		}
+
+		wg.Wait()
+
+		if won {
+			return value, nil
+		}
+
+		return value, errors.Join(errs...)
+	}
+}
+
+// taskKey is the context key of the value slot used by Lift, a new key is made for every lifted task.
+type taskKey struct{ _ int }
+
+// taskSlot receives the value of a lifted task for one caller.
+type taskSlot[T any] struct {
+	mu      sync.Mutex
+	value   T
+	written bool
+	closed  bool // closed is set when the caller returned, e.g. after a timeout, so late values are dropped.
+}
+
+// Lift applies a Callback combinator to task, e.g. Retry, Timeout or CircuitBreaker.
+// The combinator is applied once, so stateful combinators, like RateLimit, are shared by every run of the task.
+// The value reaches the caller only when the combinator runs the task with a context derived from the caller's,
+// otherwise ErrTaskNoValue is returned. This is the case of Singleflight, where the coalesced callers
+// get no value (use FlightGroup instead), and of Debounce, which runs the task later.
+// Example:
+//
+//	fetch := talker.Lift(fetchUser, func(cb talker.Callback) talker.Callback {
+//		return talker.CircuitBreaker(cb, talker.BreakerOptions{Name: "users"})
+//	})
+func Lift[T any](task Task[T], combinator func(Callback) Callback) Task[T] {
+	key := &taskKey{}
+
+	callback := combinator(func(ctx context.Context) error {
+		value, err := task(ctx)
+
+		if slot, ok := ctx.Value(key).(*taskSlot[T]); ok && err == nil {
+			slot.mu.Lock()
+			if !slot.closed {
+				slot.value, slot.written = value, true
+			}
+			slot.mu.Unlock()
+		}
+
+		return err
+	})
+
+	return func(ctx context.Context) (T, error) {
+		slot := &taskSlot[T]{}
+
+		err := callback(context.WithValue(ctx, key, slot))
+
+		slot.mu.Lock()
+		defer slot.mu.Unlock()
+
+		slot.closed = true
+
+		if err == nil && !slot.written {
+			var zero T
+
+			return zero, ErrTaskNoValue
+		}
+
+		return slot.value, err
+	}
+}
+
+// Retry runs the task with retries, check out the Retry function.
+func (t Task[T]) Retry(retries int, delay time.Duration) Task[T] {
+	return Lift(t, func(cb Callback) Callback { return Retry(cb, retries, delay) })
+}
+
+// RetryWith runs the task with a retry policy, check out the RetryWith function.
+func (t Task[T]) RetryWith(policy RetryPolicy) Task[T] {
+	return Lift(t, func(cb Callback) Callback { return RetryWith(cb, policy) })
+}
+
+// Timeout runs the task with timeout, check out the Timeout function.
+func (t Task[T]) Timeout(timeout time.Duration) Task[T] {
+	return Lift(t, func(cb Callback) Callback { return Timeout(cb, timeout) })
+}
@@ -0,0 +1,206 @@
+package talker_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Arsfiqball/csverse/talker"
+)
+
+func TestTask(t *testing.T) {
+	errFailed := talker.NewError("FAILED", "failed")
+
+	value := func(v int) talker.Task[int] {
+		return func(ctx context.Context) (int, error) { return v, nil }
+	}
+
+	fail := func(ctx context.Context) (int, error) { return 0, errFailed }
+
+	t.Run("then and map", func(t *testing.T) {
+		task := talker.Map(
+			talker.Then(value(20), func(ctx context.Context, v int) (int, error) { return v + 1, nil }),
+			strconv.Itoa,
+		)
+
+		s, err := task(context.Background())
+		if err != nil || s != "21" {
+			t.Fatalf("unexpected result: %q, %v", s, err)
+		}
+
+		called := false
+
+		_, err = talker.Then(fail, func(ctx context.Context, v int) (int, error) {
+			called = true
+			return v, nil
+		})(context.Background())
+
+		if !errors.Is(err, errFailed) || called {
+			t.Fatal("next is run after a failure")
+		}
+	})
+
+	t.Run("all", func(t *testing.T) {
+		slow := func(ctx context.Context) (int, error) {
+			time.Sleep(10 * time.Millisecond)
+			return 1, nil
+		}
+
+		values, err := talker.All(slow, value(2), value(3))(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(values) != 3 || values[0] != 1 || values[1] != 2 || values[2] != 3 {
+			t.Fatalf("unexpected values: %v", values)
+		}
+
+		cancelled := false
+
+		_, err = talker.All(fail, func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			cancelled = true
+			return 0, ctx.Err()
+		})(context.Background())
+
+		if !errors.Is(err, errFailed) || !errors.Is(err, talker.ErrParallelStep) || !cancelled {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("any", func(t *testing.T) {
+		cancelled := false
+
+		v, err := talker.Any(fail, value(7), func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			cancelled = true
+			return 0, ctx.Err()
+		})(context.Background())
+
+		if err != nil || v != 7 || !cancelled {
+			t.Fatalf("unexpected result: %d, %v", v, err)
+		}
+
+		errOther := errors.New("other")
+
+		_, err = talker.Any(fail, func(ctx context.Context) (int, error) { return 0, errOther })(context.Background())
+		if !errors.Is(err, errFailed) || !errors.Is(err, errOther) {
+			t.Fatalf("errors are not joined: %v", err)
+		}
+	})
+
+	t.Run("callback adapters", func(t *testing.T) {
+		called := false
+
+		_, err := talker.FromCallback(func(ctx context.Context) error {
+			called = true
+			return nil
+		})(context.Background())
+
+		if err != nil || !called {
+			t.Fatal("callback is not run")
+		}
+
+		if err := talker.ToCallback(talker.Task[int](fail))(context.Background()); !errors.Is(err, errFailed) {
+			t.Fatal("error is not returned")
+		}
+	})
+
+	t.Run("retry and timeout", func(t *testing.T) {
+		attempts := 0
+
+		task := talker.Task[int](func(ctx context.Context) (int, error) {
+			attempts++
+			if attempts < 3 {
+				return 0, errFailed
+			}
+			return attempts, nil
+		}).Retry(3, 0)
+
+		v, err := task(context.Background())
+		if err != nil || v != 3 {
+			t.Fatalf("unexpected result: %d, %v", v, err)
+		}
+
+		attempts = 0
+
+		v, err = task.RetryWith(talker.RetryPolicy{MaxAttempts: 1})(context.Background())
+		if err != nil || v != 3 {
+			t.Fatalf("unexpected result: %d, %v", v, err)
+		}
+
+		_, err = talker.Task[int](func(ctx context.Context) (int, error) {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}).Timeout(10 * time.Millisecond)(context.Background())
+
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("lift shares the combinator", func(t *testing.T) {
+		task := talker.Lift(value(1), func(cb talker.Callback) talker.Callback {
+			return talker.RateLimit(cb, talker.RateLimitOptions{Rate: 1})
+		})
+
+		if v, err := task(context.Background()); err != nil || v != 1 {
+			t.Fatalf("unexpected result: %d, %v", v, err)
+		}
+
+		if _, err := task(context.Background()); !errors.Is(err, talker.ErrRateLimited) {
+			t.Fatal("rate limiter is not shared")
+		}
+	})
+
+	t.Run("lift without the value", func(t *testing.T) {
+		release := make(chan struct{})
+
+		task := talker.Lift(func(ctx context.Context) (int, error) {
+			<-release
+			return 42, nil
+		}, func(cb talker.Callback) talker.Callback {
+			return talker.Singleflight(cb, nil)
+		})
+
+		errs := make(chan error, 2)
+
+		for i := 0; i < 2; i++ {
+			go func() {
+				v, err := task(context.Background())
+				if err == nil && v != 42 {
+					err = errors.New("unexpected value")
+				}
+
+				errs <- err
+			}()
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+
+		var noValue int
+
+		for i := 0; i < 2; i++ {
+			if err := <-errs; errors.Is(err, talker.ErrTaskNoValue) {
+				noValue++
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+
+		if noValue != 1 {
+			t.Fatalf("coalesced caller did not get ErrTaskNoValue: %d", noValue)
+		}
+
+		debounced := talker.Lift(value(1), func(cb talker.Callback) talker.Callback {
+			return talker.Debounce(cb, time.Millisecond)
+		})
+
+		if _, err := debounced(context.Background()); !errors.Is(err, talker.ErrTaskNoValue) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
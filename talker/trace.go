@@ -0,0 +1,211 @@
+package talker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// TraceRecorder records spans with their goroutine and timestamps,
+// and writes them in the Chrome Trace Event format that Perfetto and chrome://tracing can open.
+// Only the most recent spans are kept, up to the limit given to NewTraceRecorder.
+// This struct must be created with the NewTraceRecorder function.
+type TraceRecorder struct {
+	mu     sync.Mutex
+	epoch  time.Time
+	limit  int
+	events []traceEvent
+	next   int
+}
+
+type traceEvent struct {
+	Name  string         `json:"name"`
+	Phase string         `json:"ph"`
+	Ts    float64        `json:"ts"`
+	Dur   float64        `json:"dur"`
+	Pid   int            `json:"pid"`
+	Tid   uint64         `json:"tid"`
+	Args  map[string]any `json:"args,omitempty"`
+
+	start time.Time
+}
+
+// NewTraceRecorder creates a new TraceRecorder that keeps up to limit spans, default is 100000.
+// Example:
+//
+//	tracer := talker.NewTraceRecorder(0)
+//	pwr := talker.NewPower().WithSpanHook(tracer.Hook())
+//
+//	proc := talker.Process{
+//		// ...
+//		Stop:   tracer.Save("trace.json"), // Open it with https://ui.perfetto.dev
+//		Tracer: tracer,                    // Capture a window with GET /debug/trace?seconds=5
+//	}
+func NewTraceRecorder(limit int) *TraceRecorder {
+	if limit <= 0 {
+		limit = 100000
+	}
+
+	return &TraceRecorder{epoch: time.Now(), limit: limit}
+}
+
+// Hook returns a SpanHook that records the span when it ends.
+func (r *TraceRecorder) Hook() SpanHook {
+	return func(ctx context.Context, name string, attrs map[string]any) (context.Context, func()) {
+		tid := goroutineID()
+		start := time.Now()
+
+		return ctx, func() {
+			r.record(traceEvent{
+				Name:  name,
+				Phase: "X",
+				Ts:    float64(start.Sub(r.epoch).Nanoseconds()) / 1e3,
+				Dur:   float64(time.Since(start).Nanoseconds()) / 1e3,
+				Pid:   os.Getpid(),
+				Tid:   tid,
+				Args:  jsonParams(attrs),
+				start: start,
+			})
+		}
+	}
+}
+
+func (r *TraceRecorder) record(e traceEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.events) < r.limit {
+		r.events = append(r.events, e)
+		return
+	}
+
+	r.events[r.next] = e
+	r.next = (r.next + 1) % r.limit
+}
+
+// WriteTo writes every recorded span as Chrome Trace Event JSON.
+func (r *TraceRecorder) WriteTo(w io.Writer) (int64, error) {
+	return r.writeSince(w, time.Time{})
+}
+
+// Save returns a Callback that writes every recorded span to the file at the given path.
+// It can be used as (part of) Process.Stop to keep the trace at shutdown.
+func (r *TraceRecorder) Save(path string) Callback {
+	return func(ctx context.Context) error {
+		f, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+
+		if _, err := r.WriteTo(f); err != nil {
+			f.Close()
+			return err
+		}
+
+		return f.Close()
+	}
+}
+
+// ServeHTTP captures the spans started in the next N seconds and writes them as Chrome Trace Event JSON.
+// N is taken from the "seconds" query parameter, default is 5.
+func (r *TraceRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	seconds := 5
+
+	if s := req.URL.Query().Get("seconds"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid seconds", http.StatusBadRequest)
+			return
+		}
+
+		seconds = n
+	}
+
+	since := time.Now()
+	timer := time.NewTimer(time.Duration(seconds) * time.Second)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-req.Context().Done():
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="trace.json"`)
+
+	_, _ = r.writeSince(w, since)
+}
+
+func (r *TraceRecorder) writeSince(w io.Writer, since time.Time) (int64, error) {
+	r.mu.Lock()
+	events := make([]traceEvent, 0, len(r.events))
+
+	for i := 0; i < len(r.events); i++ {
+		e := r.events[(r.next+i)%len(r.events)]
+
+		if !e.start.Before(since) {
+			events = append(events, e)
+		}
+	}
+	r.mu.Unlock()
+
+	b, err := json.Marshal(struct {
+		TraceEvents     []traceEvent `json:"traceEvents"`
+		DisplayTimeUnit string       `json:"displayTimeUnit"`
+	}{events, "ms"})
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(b)
+
+	return int64(n), err
+}
+
+// jsonParams keeps the params that can be written as JSON and formats the rest, e.g. functions or NaN.
+func jsonParams(attrs map[string]any) map[string]any {
+	if len(attrs) == 0 {
+		return nil
+	}
+
+	params := make(map[string]any, len(attrs))
+
+	for key, value := range attrs {
+		switch value.(type) {
+		case nil, string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			params[key] = value
+		default:
+			if b, err := json.Marshal(value); err == nil {
+				params[key] = json.RawMessage(b)
+			} else {
+				params[key] = fmt.Sprint(value)
+			}
+		}
+	}
+
+	return params
+}
+
+func goroutineID() uint64 {
+	var buf [64]byte
+
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+
+	return id
+}
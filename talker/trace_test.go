@@ -0,0 +1,97 @@
+package talker_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Arsfiqball/csverse/talker"
+)
+
+type chromeTrace struct {
+	TraceEvents []struct {
+		Name string         `json:"name"`
+		Ph   string         `json:"ph"`
+		Tid  uint64         `json:"tid"`
+		Args map[string]any `json:"args"`
+	} `json:"traceEvents"`
+}
+
+func TestTraceRecorder(t *testing.T) {
+	t.Run("write", func(t *testing.T) {
+		tracer := talker.NewTraceRecorder(2)
+		ctx := talker.NewPower().WithSpanHook(tracer.Hook()).Context(context.Background(), "test")
+
+		for _, name := range []string{"a", "b", "c"} {
+			_, end := talker.Span(ctx, name, talker.Params{"fn": func() {}})
+			end()
+		}
+
+		var buf bytes.Buffer
+		if _, err := tracer.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+
+		var trace chromeTrace
+		if err := json.Unmarshal(buf.Bytes(), &trace); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(trace.TraceEvents) != 2 || trace.TraceEvents[0].Name != "b" || trace.TraceEvents[1].Name != "c" {
+			t.Fatalf("unexpected events: %+v", trace.TraceEvents)
+		}
+
+		if trace.TraceEvents[0].Ph != "X" || trace.TraceEvents[0].Tid == 0 {
+			t.Fatal("event is not a complete event with a goroutine id")
+		}
+	})
+
+	t.Run("save", func(t *testing.T) {
+		tracer := talker.NewTraceRecorder(0)
+		path := filepath.Join(t.TempDir(), "trace.json")
+
+		if err := tracer.Save(path)(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := os.Stat(path); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("capture", func(t *testing.T) {
+		tracer := talker.NewTraceRecorder(0)
+		ctx := talker.NewPower().WithSpanHook(tracer.Hook()).Context(context.Background(), "test")
+
+		_, end := talker.Span(ctx, "before", nil)
+		end()
+
+		rec := httptest.NewRecorder()
+		done := make(chan struct{})
+
+		go func() {
+			tracer.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/trace?seconds=1", nil))
+			close(done)
+		}()
+
+		time.Sleep(100 * time.Millisecond) // let the capture window start
+
+		_, end = talker.Span(ctx, "during", nil)
+		end()
+		<-done
+
+		var trace chromeTrace
+		if err := json.Unmarshal(rec.Body.Bytes(), &trace); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(trace.TraceEvents) != 1 || trace.TraceEvents[0].Name != "during" {
+			t.Fatalf("unexpected events: %+v", trace.TraceEvents)
+		}
+	})
+}
@@ -0,0 +1,455 @@
+package talker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrWorkflowNotFound is the error returned when a workflow run is not in the checkpoint store.
+	ErrWorkflowNotFound = NewError("WORKFLOW_NOT_FOUND", "workflow run not found")
+	// ErrWorkflowMismatch is the error returned when a workflow run ID is used by another workflow.
+	ErrWorkflowMismatch = NewError("WORKFLOW_MISMATCH", "workflow run belongs to another workflow")
+	// ErrWorkflowStep wraps the error of a failed workflow step, the data of the error is the workflow run ID.
+	ErrWorkflowStep = NewError("WORKFLOW_STEP", "workflow step failed")
+	// ErrWorkflowOutput is the error returned by StepOutput when the output can not be read.
+	ErrWorkflowOutput = NewError("WORKFLOW_OUTPUT", "workflow step output not found")
+)
+
+const workflowContextKey = PowerContextKey("workflow_context")
+
+// WorkflowStatus is the status of a workflow run or of one of its steps.
+type WorkflowStatus string
+
+const (
+	WorkflowRunning   WorkflowStatus = "running"   // WorkflowRunning is the status of a run that is running, or that stopped with a crash.
+	WorkflowSucceeded WorkflowStatus = "succeeded" // WorkflowSucceeded is the status of a run or a step that completed.
+	WorkflowFailed    WorkflowStatus = "failed"    // WorkflowFailed is the status of a run or a step that returned an error.
+)
+
+// WorkflowRun is the checkpoint of a workflow run, it is saved after every step.
+type WorkflowRun struct {
+	ID       string               `json:"id"`
+	Workflow string               `json:"workflow"`
+	Status   WorkflowStatus       `json:"status"`
+	Attempts int                  `json:"attempts"` // Attempts is the number of times the run was started or resumed.
+	Started  time.Time            `json:"started"`
+	Updated  time.Time            `json:"updated"`
+	Error    string               `json:"error,omitempty"` // Error is the error of the last failed step.
+	Steps    []WorkflowStepRecord `json:"steps"`           // Steps are the steps that ran, in the order they completed.
+}
+
+// WorkflowStepRecord is the checkpoint of a workflow step.
+type WorkflowStepRecord struct {
+	Name     string          `json:"name"`
+	Status   WorkflowStatus  `json:"status"`
+	Started  time.Time       `json:"started"`
+	Duration time.Duration   `json:"duration"`
+	Output   json.RawMessage `json:"output,omitempty"` // Output is the JSON value returned by a step added with TaskStep.
+	Error    string          `json:"error,omitempty"`
+}
+
+// Step returns the record of the step with the given name.
+func (r WorkflowRun) Step(name string) (WorkflowStepRecord, bool) {
+	for _, step := range r.Steps {
+		if step.Name == name {
+			return step, true
+		}
+	}
+
+	return WorkflowStepRecord{}, false
+}
+
+// CheckpointStore is a storage for workflow runs.
+type CheckpointStore interface {
+	Save(ctx context.Context, run WorkflowRun) error          // Save creates or replaces the run.
+	Load(ctx context.Context, id string) (WorkflowRun, error) // Load returns ErrWorkflowNotFound when the run does not exist.
+	List(ctx context.Context) ([]WorkflowRun, error)          // List returns every run.
+}
+
+type workflowStep struct {
+	name string
+	run  func(ctx context.Context) (json.RawMessage, error)
+}
+
+// workflowState is the state of the running step, it is kept in the context.
+type workflowState struct {
+	run  *WorkflowRun
+	step string
+}
+
+// Workflow runs steps sequentially and saves a checkpoint after every step,
+// so a run that failed or crashed can be resumed without running the completed steps again.
+// The same run must not be run concurrently.
+// This struct must be created with the NewWorkflow function.
+type Workflow struct {
+	name  string
+	store CheckpointStore
+	steps []workflowStep
+}
+
+// NewWorkflow creates a new Workflow with the given name, the runs are saved to store.
+// Example:
+//
+//	store, err := talker.NewDirCheckpointStore("var/checkpoints")
+//	if err != nil {
+//		return err
+//	}
+//
+//	wf := talker.NewWorkflow("import", store)
+//
+//	talker.TaskStep(wf, "download", func(ctx context.Context) (string, error) {
+//		return download(ctx, talker.IdempotencyKey(ctx)) // e.g. "2024-01-01/download"
+//	})
+//
+//	wf.Step("load", func(ctx context.Context) error {
+//		path, err := talker.StepOutput[string](ctx, "download")
+//		if err != nil {
+//			return err
+//		}
+//
+//		return load(ctx, path)
+//	})
+//
+//	err = wf.Run(ctx, "2024-01-01") // running it again skips the completed steps
+func NewWorkflow(name string, store CheckpointStore) *Workflow {
+	return &Workflow{name: name, store: store}
+}
+
+// Step adds a step without output, it returns the workflow so calls can be chained.
+// It panics when the name is already used.
+func (w *Workflow) Step(name string, callback Callback) *Workflow {
+	return w.add(name, func(ctx context.Context) (json.RawMessage, error) {
+		return nil, callback(ctx)
+	})
+}
+
+// TaskStep adds a step whose value is saved as JSON, it can be read by the next steps with StepOutput,
+// also when the step itself is skipped because it completed in a previous attempt.
+// It panics when the name is already used.
+func TaskStep[T any](w *Workflow, name string, task Task[T]) *Workflow {
+	return w.add(name, func(ctx context.Context) (json.RawMessage, error) {
+		value, err := task(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return json.Marshal(value)
+	})
+}
+
+func (w *Workflow) add(name string, run func(ctx context.Context) (json.RawMessage, error)) *Workflow {
+	for _, step := range w.steps {
+		if step.name == name {
+			panic(fmt.Sprintf("talker: workflow step %s is added twice", name))
+		}
+	}
+
+	w.steps = append(w.steps, workflowStep{name: name, run: run})
+
+	return w
+}
+
+// IdempotencyKey returns the key of the running workflow step, "<run ID>/<step name>".
+// It is the same for every attempt of the step, so it can be given to external services to deduplicate requests.
+// It returns an empty string outside of a workflow step.
+func IdempotencyKey(ctx context.Context) string {
+	state, ok := ctx.Value(workflowContextKey).(*workflowState)
+	if !ok {
+		return ""
+	}
+
+	return state.run.ID + "/" + state.step
+}
+
+// StepOutput decodes the output of a completed step of the running workflow.
+// It returns ErrWorkflowOutput when it is called outside of a workflow step, or when the step has no output.
+func StepOutput[T any](ctx context.Context, step string) (T, error) {
+	var value T
+
+	state, ok := ctx.Value(workflowContextKey).(*workflowState)
+	if !ok {
+		return value, ErrWorkflowOutput.WithInfo("workflow step output is read outside of a workflow step")
+	}
+
+	record, ok := state.run.Step(step)
+	if !ok || record.Status != WorkflowSucceeded || record.Output == nil {
+		return value, ErrWorkflowOutput.WithInfo(fmt.Sprintf("workflow step %s has no output", step))
+	}
+
+	if err := json.Unmarshal(record.Output, &value); err != nil {
+		return value, ErrWorkflowOutput.Wrap(err).WithInfo(fmt.Sprintf("workflow step %s output can not be decoded: %s", step, err))
+	}
+
+	return value, nil
+}
+
+// Callback returns a Callback that runs the workflow with the given run ID.
+func (w *Workflow) Callback(id string) Callback {
+	return func(ctx context.Context) error {
+		return w.Run(ctx, id)
+	}
+}
+
+// Run runs the workflow with the given run ID, the steps that completed in a previous attempt are skipped.
+// A run that succeeded is not run again. When a step fails, the run is saved as failed and ErrWorkflowStep is returned.
+func (w *Workflow) Run(ctx context.Context, id string) error {
+	run, err := w.store.Load(ctx, id)
+	if errors.Is(err, ErrWorkflowNotFound) {
+		run = WorkflowRun{ID: id, Workflow: w.name, Started: time.Now(), Steps: []WorkflowStepRecord{}}
+	} else if err != nil {
+		return err
+	}
+
+	return w.run(ctx, run)
+}
+
+// Resume runs a run that exists in the store again, e.g. one returned by List with WorkflowFailed.
+func (w *Workflow) Resume(ctx context.Context, id string) error {
+	run, err := w.Inspect(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return w.run(ctx, run)
+}
+
+// Inspect returns the checkpoint of the run with the given ID.
+func (w *Workflow) Inspect(ctx context.Context, id string) (WorkflowRun, error) {
+	run, err := w.store.Load(ctx, id)
+	if err != nil {
+		return run, err
+	}
+
+	if run.Workflow != w.name {
+		return run, ErrWorkflowMismatch.WithInfo(fmt.Sprintf("workflow run %s belongs to workflow %s", id, run.Workflow))
+	}
+
+	return run, nil
+}
+
+// List returns the runs of the workflow with the given status, sorted by start time.
+// Every run is returned when status is empty.
+func (w *Workflow) List(ctx context.Context, status WorkflowStatus) ([]WorkflowRun, error) {
+	all, err := w.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	runs := []WorkflowRun{}
+
+	for _, run := range all {
+		if run.Workflow == w.name && (status == "" || run.Status == status) {
+			runs = append(runs, run)
+		}
+	}
+
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Started.Before(runs[j].Started) })
+
+	return runs, nil
+}
+
+func (w *Workflow) run(ctx context.Context, run WorkflowRun) error {
+	if run.Workflow != w.name {
+		return ErrWorkflowMismatch.WithInfo(fmt.Sprintf("workflow run %s belongs to workflow %s", run.ID, run.Workflow))
+	}
+
+	if run.Status == WorkflowSucceeded {
+		return nil
+	}
+
+	ctx, end := combinatorSpan(ctx, "talker.workflow", Params{"workflow": w.name, "run": run.ID})
+	defer end()
+
+	run.Status = WorkflowRunning
+	run.Attempts++
+	run.Error = ""
+
+	if err := w.save(ctx, &run); err != nil {
+		return err
+	}
+
+	for _, step := range w.steps {
+		if record, ok := run.Step(step.name); ok && record.Status == WorkflowSucceeded {
+			continue
+		}
+
+		stepCtx := context.WithValue(ctx, workflowContextKey, &workflowState{run: &run, step: step.name})
+		stepCtx, endStep := combinatorSpan(stepCtx, "talker.workflow.step", Params{"name": step.name})
+
+		start := time.Now()
+		output, err := step.run(stepCtx)
+		endStep()
+
+		record := WorkflowStepRecord{Name: step.name, Status: WorkflowSucceeded, Started: start, Duration: time.Since(start), Output: output}
+
+		if err != nil {
+			record.Status = WorkflowFailed
+			record.Output = nil
+			record.Error = err.Error()
+		}
+
+		run.Steps = append(removeStepRecord(run.Steps, step.name), record)
+
+		if err != nil {
+			run.Status = WorkflowFailed
+			run.Error = err.Error()
+
+			stepErr := ErrWorkflowStep.
+				Wrap(err).
+				WithInfo(fmt.Sprintf("workflow %s step %s failed: %s", w.name, step.name, err)).
+				WithData(run.ID)
+
+			if saveErr := w.save(ctx, &run); saveErr != nil {
+				return errors.Join(stepErr, saveErr)
+			}
+
+			return stepErr
+		}
+
+		if err := w.save(ctx, &run); err != nil {
+			return err
+		}
+	}
+
+	run.Status = WorkflowSucceeded
+
+	return w.save(ctx, &run)
+}
+
+func (w *Workflow) save(ctx context.Context, run *WorkflowRun) error {
+	run.Updated = time.Now()
+
+	// Checkpoints are saved even when the context is done, so the work that was done is not lost.
+	return w.store.Save(context.WithoutCancel(ctx), *run)
+}
+
+func removeStepRecord(records []WorkflowStepRecord, name string) []WorkflowStepRecord {
+	kept := make([]WorkflowStepRecord, 0, len(records))
+
+	for _, record := range records {
+		if record.Name != name {
+			kept = append(kept, record)
+		}
+	}
+
+	return kept
+}
+
+// DirCheckpointStore is a CheckpointStore that saves every run as a JSON file in a local directory.
+// This struct must be created with the NewDirCheckpointStore function.
+type DirCheckpointStore struct {
+	mu  sync.Mutex
+	dir string
+}
+
+var _ CheckpointStore = &DirCheckpointStore{}
+
+// NewDirCheckpointStore creates the directory at the given path when it does not exist.
+func NewDirCheckpointStore(dir string) (*DirCheckpointStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+
+	return &DirCheckpointStore{dir: dir}, nil
+}
+
+func (s *DirCheckpointStore) path(id string) string {
+	return filepath.Join(s.dir, url.PathEscape(id)+".json")
+}
+
+// Save writes the run to a temporary file and renames it, so a crash never leaves a partial checkpoint.
+func (s *DirCheckpointStore) Save(ctx context.Context, run WorkflowRun) error {
+	b, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.CreateTemp(s.dir, ".checkpoint-*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(f.Name()) // no-op after the rename
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), s.path(run.ID))
+}
+
+// Load reads the run with the given ID.
+func (s *DirCheckpointStore) Load(ctx context.Context, id string) (WorkflowRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.load(s.path(id))
+}
+
+func (s *DirCheckpointStore) load(path string) (WorkflowRun, error) {
+	var run WorkflowRun
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return run, ErrWorkflowNotFound.Wrap(err)
+	} else if err != nil {
+		return run, err
+	}
+
+	if err := json.Unmarshal(b, &run); err != nil {
+		return run, fmt.Errorf("checkpoint %s can not be decoded: %w", path, err)
+	}
+
+	return run, nil
+}
+
+// List reads every run in the directory.
+func (s *DirCheckpointStore) List(ctx context.Context) ([]WorkflowRun, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	runs := []WorkflowRun{}
+
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		run, err := s.load(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		runs = append(runs, run)
+	}
+
+	return runs, nil
+}
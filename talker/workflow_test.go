@@ -0,0 +1,182 @@
+package talker_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/Arsfiqball/csverse/talker"
+)
+
+func TestWorkflow(t *testing.T) {
+	errFailed := talker.NewError("FAILED", "failed")
+
+	newStore := func(t *testing.T) *talker.DirCheckpointStore {
+		store, err := talker.NewDirCheckpointStore(filepath.Join(t.TempDir(), "checkpoints"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return store
+	}
+
+	t.Run("resume skips completed steps", func(t *testing.T) {
+		store := newStore(t)
+		calls := map[string]int{}
+		keys := []string{}
+		failLoad := true
+
+		wf := talker.NewWorkflow("import", store)
+
+		talker.TaskStep(wf, "download", func(ctx context.Context) (string, error) {
+			calls["download"]++
+			keys = append(keys, talker.IdempotencyKey(ctx))
+			return "/tmp/users.csv", nil
+		})
+
+		wf.Step("load", func(ctx context.Context) error {
+			calls["load"]++
+			keys = append(keys, talker.IdempotencyKey(ctx))
+
+			path, err := talker.StepOutput[string](ctx, "download")
+			if err != nil {
+				return err
+			}
+
+			if path != "/tmp/users.csv" {
+				t.Errorf("unexpected output: %s", path)
+			}
+
+			if failLoad {
+				return errFailed
+			}
+
+			return nil
+		})
+
+		err := wf.Run(context.Background(), "2024/01/01")
+		if !errors.Is(err, talker.ErrWorkflowStep) || !errors.Is(err, errFailed) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		failed, err := wf.List(context.Background(), talker.WorkflowFailed)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(failed) != 1 || failed[0].ID != "2024/01/01" || failed[0].Error != "failed" {
+			t.Fatalf("unexpected failed runs: %+v", failed)
+		}
+
+		run, err := wf.Inspect(context.Background(), "2024/01/01")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if step, ok := run.Step("download"); !ok || step.Status != talker.WorkflowSucceeded || string(step.Output) != `"/tmp/users.csv"` {
+			t.Fatalf("unexpected step: %+v", step)
+		}
+
+		if step, ok := run.Step("load"); !ok || step.Status != talker.WorkflowFailed || step.Error != "failed" {
+			t.Fatalf("unexpected step: %+v", step)
+		}
+
+		// The cause of the failure is fixed, the download is not run again.
+		failLoad = false
+
+		if err := wf.Resume(context.Background(), "2024/01/01"); err != nil {
+			t.Fatal(err)
+		}
+
+		if calls["download"] != 1 || calls["load"] != 2 {
+			t.Fatalf("unexpected calls: %v", calls)
+		}
+
+		if keys[0] != "2024/01/01/download" || keys[1] != "2024/01/01/load" || keys[2] != keys[1] {
+			t.Fatalf("unexpected idempotency keys: %v", keys)
+		}
+
+		run, err = wf.Inspect(context.Background(), "2024/01/01")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if run.Status != talker.WorkflowSucceeded || run.Attempts != 2 || len(run.Steps) != 2 {
+			t.Fatalf("unexpected run: %+v", run)
+		}
+
+		// A succeeded run is not run again.
+		if err := wf.Run(context.Background(), "2024/01/01"); err != nil {
+			t.Fatal(err)
+		}
+
+		if calls["download"] != 1 || calls["load"] != 2 {
+			t.Fatalf("unexpected calls: %v", calls)
+		}
+	})
+
+	t.Run("checkpoints are kept by the store", func(t *testing.T) {
+		store := newStore(t)
+		ran := 0
+
+		step := func(ctx context.Context) error {
+			ran++
+			return nil
+		}
+
+		if err := talker.NewWorkflow("report", store).Step("a", step).Run(context.Background(), "r1"); err != nil {
+			t.Fatal(err)
+		}
+
+		wf := talker.NewWorkflow("report", store).Step("a", step).Step("b", step)
+
+		if err := wf.Run(context.Background(), "r1"); err != nil {
+			t.Fatal(err)
+		}
+
+		if ran != 1 {
+			t.Fatal("succeeded run is run again")
+		}
+
+		if err := wf.Run(context.Background(), "r2"); err != nil {
+			t.Fatal(err)
+		}
+
+		runs, err := wf.List(context.Background(), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(runs) != 2 || runs[0].ID != "r1" || runs[1].ID != "r2" {
+			t.Fatalf("unexpected runs: %+v", runs)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		store := newStore(t)
+		noop := func(ctx context.Context) error { return nil }
+
+		if err := talker.NewWorkflow("a", store).Step("s", noop).Run(context.Background(), "r1"); err != nil {
+			t.Fatal(err)
+		}
+
+		other := talker.NewWorkflow("b", store).Step("s", noop)
+
+		if err := other.Run(context.Background(), "r1"); !errors.Is(err, talker.ErrWorkflowMismatch) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := other.Resume(context.Background(), "missing"); !errors.Is(err, talker.ErrWorkflowNotFound) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := talker.StepOutput[string](context.Background(), "s"); !errors.Is(err, talker.ErrWorkflowOutput) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if talker.IdempotencyKey(context.Background()) != "" {
+			t.Fatal("idempotency key is set outside of a workflow")
+		}
+	})
+}